@@ -1,7 +1,11 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -16,13 +20,66 @@ import (
 )
 
 type Config struct {
-	GithubRepo     string   `json:"githubRepo"`
-	GithubBranch   string   `json:"githubBranch"`
-	ICSFeeds       []string `json:"icsFeeds"`
-	TimeZone       string   `json:"timezone"`
-	SyncSchedule   string   `json:"syncSchedule"`
-	RepoDirectory  string   `json:"repoDirectory"`
-	ScheduleMonths int      `json:"scheduleMonths"`
+	GithubRepo         string        `json:"githubRepo"`
+	GithubBranch       string        `json:"githubBranch"`
+	ICSFeeds           []string      `json:"icsFeeds"`
+	TimeZone           string        `json:"timezone"`
+	SyncSchedule       string        `json:"syncSchedule"`
+	RepoDirectory      string        `json:"repoDirectory"`
+	ScheduleMonths     int           `json:"scheduleMonths"`
+	AllDayEvents       string        `json:"allDayEvents"`
+	WorkingHours       string        `json:"workingHours"` // Such as "mon-fri 09:00-17:00"
+	SlotMinutes        int           `json:"slotMinutes"`
+	OwnerEmails        []string      `json:"ownerEmails"`
+	TitleBusyHeuristic bool          `json:"titleBusyHeuristic"`
+	IncludeTodos       bool          `json:"includeTodos"`
+	ParseMode          string        `json:"parseMode"`
+	CacheDir           string        `json:"cacheDir"`
+	FeedMaxStaleness   time.Duration `json:"feedMaxStaleness"`
+	FeedTimeout        time.Duration `json:"feedTimeout"`
+	FetchMaxAttempts   int           `json:"fetchMaxAttempts"`
+	FetchRetryBackoff  time.Duration `json:"fetchRetryBackoff"`
+	FetchConcurrency   int           `json:"fetchConcurrency"`
+	HostConcurrency    int           `json:"hostConcurrency"`
+	FeedMaxSizeMB      int           `json:"feedMaxSizeMB"`
+	FeedCountsFile     string        `json:"feedCountsFile"`  // Event counts of the last run, empty to not check them
+	ShrinkThreshold    int           `json:"shrinkThreshold"` // Percentage of its events a feed may lose between runs
+	ShrinkGrace        time.Duration `json:"shrinkGrace"`
+	ShrinkMinEvents    int           `json:"shrinkMinEvents"` // Smallest event count that is guarded
+	RunReport          string        `json:"runReport"`
+	FetchProxy         string        `json:"fetchProxy"`   // Empty to use HTTP_PROXY and HTTPS_PROXY
+	FetchCAFiles       []string      `json:"fetchCAFiles"` // Trusted in addition to the system's CAs
+	Feeds              []FeedConfig  `json:"feeds"`        // Feeds with their own settings, from FEEDS_CONFIG
+}
+
+// FeedConfig holds the settings of a feed listed in FEEDS_CONFIG
+type FeedConfig struct {
+	Source  string      `json:"source"`
+	Type    string      `json:"type,omitempty"`    // "ics" (default) or "caldav"
+	Timeout string      `json:"timeout,omitempty"` // Overrides FEED_TIMEOUT, such as "90s"
+	Auth    *AuthConfig `json:"auth,omitempty"`
+	TLS     *TLSConfig  `json:"tls,omitempty"`
+}
+
+// TLSConfig holds a feed's client certificate and, for the local machine or
+// the host named in it only, a pinned server certificate or disabled
+// verification
+type TLSConfig struct {
+	Cert               string `json:"cert,omitempty"` // PEM file
+	Key                string `json:"key,omitempty"`  // PEM file
+	PinSHA256          string `json:"pinSHA256,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty"`
+	Host               string `json:"host,omitempty"` // Network host allowed to be pinned or unverified
+}
+
+// AuthConfig holds a feed's credentials: HTTP Basic when a username is set,
+// otherwise a bearer token, plus any extra headers. Secrets may be given as
+// env:NAME or file:PATH to keep them out of the configuration file.
+type AuthConfig struct {
+	Username string            `json:"username,omitempty"`
+	Password string            `json:"password,omitempty"`
+	Token    string            `json:"token,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
 }
 
 func main() {
@@ -46,8 +103,48 @@ func main() {
 	}
 
 	fetcher := calendar.NewFetcher()
+	fetcher.SetCacheDir(config.CacheDir)
+	fetcher.SetMaxStaleness(config.FeedMaxStaleness)
+	fetcher.SetTimeout(config.FeedTimeout)
+	retry := calendar.DefaultRetryPolicy()
+	retry.MaxAttempts = config.FetchMaxAttempts
+	retry.InitialBackoff = config.FetchRetryBackoff
+	fetcher.SetRetryPolicy(retry)
+	fetcher.SetConcurrency(config.FetchConcurrency)
+	fetcher.SetHostConcurrency(config.HostConcurrency)
+	fetcher.SetMaxFeedSize(int64(config.FeedMaxSizeMB) << 20)
+	if err := fetcher.SetProxy(config.FetchProxy); err != nil {
+		logger.Error("Failed to configure proxy: %v", err)
+		os.Exit(1)
+	}
+	for _, caFile := range config.FetchCAFiles {
+		if err := fetcher.AddCAFile(caFile); err != nil {
+			logger.Error("Failed to load CA file: %v", err)
+			os.Exit(1)
+		}
+	}
+	var guard *calendar.ShrinkGuard
+	if config.FeedCountsFile != "" && config.ShrinkThreshold > 0 {
+		guard = calendar.NewShrinkGuard(config.FeedCountsFile)
+		guard.SetThreshold(float64(config.ShrinkThreshold) / 100)
+		guard.SetGracePeriod(config.ShrinkGrace)
+		guard.SetMinEvents(config.ShrinkMinEvents)
+		if err := guard.Load(); err != nil {
+			logger.Error("Failed to load feed counts, starting over: %v", err)
+		}
+		fetcher.SetShrinkGuard(guard)
+	}
 	parser := calendar.NewParser(tz)
+	parser.SetOwnerEmails(config.OwnerEmails)
+	parser.SetTitleHeuristic(config.TitleBusyHeuristic)
+	parser.SetIncludeTodos(config.IncludeTodos)
+	parser.SetMode(calendar.ParseMode(config.ParseMode))
 	merger := calendar.NewMerger(tz)
+	merger.SetAllDayMode(calendar.AllDayMode(config.AllDayEvents))
+	// Working hours were validated when the configuration was loaded
+	hours, _ := calendar.ParseWorkingHours(config.WorkingHours)
+	merger.SetWorkingHours(hours)
+	merger.SetSlotLength(time.Duration(config.SlotMinutes) * time.Minute)
 	templateDir := filepath.Join("internal", "templates")
 	gen, err := generator.NewGenerator(templateDir)
 	if err != nil {
@@ -66,39 +163,81 @@ func main() {
 	}
 	logger.Debug("Confirmed valid git repository at %s", config.RepoDirectory)
 
+	// Determine the range of weeks to render
+	now := time.Now().In(tz)
+	startDate := now.AddDate(0, -1, 0) // Start from 1 month ago
+	endDate := now.AddDate(0, config.ScheduleMonths, 0)
+	logger.Debug("Date range: %s to %s", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+
+	// Bound recurrence expansion to the weeks we render
+	startYear, startWeek := startDate.ISOWeek()
+	parser.SetWindow(calendar.FirstDayOfISOWeek(startYear, startWeek, tz), endDate.AddDate(0, 0, 7))
+
 	// Process calendars
 	logger.Debug("Processing calendar feeds")
 	var allEvents []calendar.Event
-	for _, feedURL := range config.ICSFeeds {
-		logger.Debug("Processing feed: %s", feedURL)
-		feed := calendar.Feed{
-			Source:   feedURL,
-			IsURL:    strings.HasPrefix(feedURL, "http"),
-			TimeZone: tz,
-		}
-
-		logger.Debug("Fetching feed data")
-		data, err := fetcher.Fetch(feed)
-		if err != nil {
-			logger.Error("Failed to fetch feed %s: %v", feedURL, err)
+	var staleFeeds []calendar.StaleFeed
+	var shrunkFeeds []string
+	var missingFeeds []string // Failed without a copy to fall back to
+	report := &RunReport{StartedAt: now}
+	feeds, err := buildFeeds(config, tz)
+	if err != nil {
+		logger.Error("Failed to configure feeds: %v", err)
+		os.Exit(1)
+	}
+	logger.Debug("Fetching %d feeds, %d at a time", len(feeds), config.FetchConcurrency)
+	for _, result := range fetcher.FetchAll(context.Background(), feeds, parser) {
+		feed := result.Feed
+		logger.Debug("Processing feed: %s", feed)
+		report.addFeed(result)
+
+		if err := result.Err; err != nil {
+			var perr *calendar.ParseError
+			var serr *calendar.ShrinkError
+			switch {
+			case errors.As(err, &serr):
+				logger.Error("Feed %s shrank from %d to %d events and has no cached copy to fall back to",
+					feed, serr.Previous, serr.Current)
+				shrunkFeeds = append(shrunkFeeds, feed.ID)
+			case errors.As(err, &perr):
+				logger.Error("Failed to parse feed %s: %v", feed, err)
+				missingFeeds = append(missingFeeds, feed.ID)
+			default:
+				logger.Error("Failed to fetch feed %s: %v", feed, err)
+				missingFeeds = append(missingFeeds, feed.ID)
+			}
 			continue
 		}
-
-		events, err := parser.Parse(data)
-		if err != nil {
-			logger.Error("Failed to parse feed %s: %v", feedURL, err)
-			continue
+		if result.Cache == calendar.CacheHit {
+			logger.Debug("Feed %s unchanged, using cached copy", feed.ID)
+		}
+		if result.Stale {
+			logger.Error("Failed to refresh feed %s, using copy from %s: %v",
+				feed, result.FetchedAt.Format(time.RFC3339), result.FetchErr)
+			staleFeeds = append(staleFeeds, calendar.StaleFeed{ID: feed.ID, FetchedAt: result.FetchedAt})
 		}
+		logParseErrors(feed, result.ParseErrors)
 
-		allEvents = append(allEvents, events...)
+		allEvents = append(allEvents, result.Events...)
+	}
+
+	if guard != nil {
+		if err := guard.Save(); err != nil {
+			logger.Error("Failed to save feed counts: %v", err)
+		}
+	}
+	// Publishing without a collapsed feed would show all of its time as free
+	if len(shrunkFeeds) > 0 {
+		report.Reason = fmt.Sprintf("feeds lost too many events: %s", strings.Join(shrunkFeeds, ", "))
+		logger.Error("Not publishing schedules, %s", report.Reason)
+		if err := report.write(config.RunReport); err != nil {
+			logger.Error("%v", err)
+		}
+		os.Exit(1)
 	}
 
 	// Generate schedules for configured time range
 	logger.Debug("Generating schedules")
-	now := time.Now().In(tz)
-	startDate := now.AddDate(0, -1, 0) // Start from 1 month ago
-	endDate := now.AddDate(0, config.ScheduleMonths, 0)
-	logger.Debug("Date range: %s to %s", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
 
 	// Track which files we write for commit message
 	var updatedFiles []string
@@ -110,6 +249,8 @@ func main() {
 
 		// Generate schedule for this week
 		schedule := merger.MergeEvents(allEvents, year, week)
+		schedule.StaleFeeds = staleFeeds
+		schedule.MissingFeeds = missingFeeds
 		content, err := gen.GenerateWeekSchedule(schedule)
 		if err != nil {
 			logger.Error("Failed to generate schedule for week %d-%d: %v", year, week, err)
@@ -200,10 +341,125 @@ func main() {
 		logger.Debug("Skipping push in test mode")
 	}
 
+	report.Published = true
+	if err := report.write(config.RunReport); err != nil {
+		logger.Error("%v", err)
+	}
+
 	logger.Info("Successfully updated schedules: %s", strings.Join(updatedFiles, ", "))
 	logger.Debug("dotcal application completed successfully")
 }
 
+// buildFeeds lists the ICS_FEEDS entries followed by the feeds from
+// FEEDS_CONFIG, reading their credentials
+func buildFeeds(config *Config, tz *time.Location) ([]calendar.Feed, error) {
+	sources := make([]FeedConfig, 0, len(config.ICSFeeds)+len(config.Feeds))
+	for _, source := range config.ICSFeeds {
+		sources = append(sources, FeedConfig{Source: source})
+	}
+	sources = append(sources, config.Feeds...)
+
+	feeds := make([]calendar.Feed, 0, len(sources))
+	for i, fc := range sources {
+		// Timeouts were validated when the configuration was loaded
+		timeout, _ := time.ParseDuration(fc.Timeout)
+		feed := calendar.Feed{
+			ID:       fmt.Sprintf("feed-%d", i+1),
+			Source:   fc.Source,
+			IsURL:    calendar.IsURLSource(fc.Source),
+			Type:     feedType(fc.Type),
+			Timeout:  timeout,
+			TimeZone: tz,
+		}
+		if fc.Auth != nil {
+			auth, err := fc.Auth.resolve()
+			if err != nil {
+				return nil, fmt.Errorf("credentials of %s: %w", feed, err)
+			}
+			feed.Auth = auth
+		}
+		if fc.TLS != nil {
+			feed.TLS = &calendar.TLSConfig{
+				CertFile:           fc.TLS.Cert,
+				KeyFile:            fc.TLS.Key,
+				PinSHA256:          fc.TLS.PinSHA256,
+				InsecureSkipVerify: fc.TLS.InsecureSkipVerify,
+				Host:               fc.TLS.Host,
+			}
+		}
+		feeds = append(feeds, feed)
+	}
+	return feeds, nil
+}
+
+// feedType maps a FEEDS_CONFIG type onto the calendar package's
+func feedType(name string) calendar.FeedType {
+	if name == "caldav" {
+		return calendar.FeedCalDAV
+	}
+	return calendar.FeedICS
+}
+
+// resolve reads the secrets of the credentials
+func (ac *AuthConfig) resolve() (*calendar.Auth, error) {
+	auth := &calendar.Auth{Username: ac.Username, Headers: make(map[string]string)}
+	var err error
+	if auth.Password, err = resolveSecret(ac.Password); err != nil {
+		return nil, fmt.Errorf("password: %w", err)
+	}
+	if auth.Token, err = resolveSecret(ac.Token); err != nil {
+		return nil, fmt.Errorf("token: %w", err)
+	}
+	for name, value := range ac.Headers {
+		if auth.Headers[name], err = resolveSecret(value); err != nil {
+			return nil, fmt.Errorf("header %s: %w", name, err)
+		}
+	}
+	return auth, nil
+}
+
+// resolveSecret returns a secret given as env:NAME, file:PATH or literally
+func resolveSecret(ref string) (string, error) {
+	switch {
+	case strings.HasPrefix(ref, "env:"):
+		name := strings.TrimPrefix(ref, "env:")
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return value, nil
+	case strings.HasPrefix(ref, "file:"):
+		data, err := os.ReadFile(strings.TrimPrefix(ref, "file:"))
+		if err != nil {
+			return "", fmt.Errorf("reading secret: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	default:
+		return ref, nil
+	}
+}
+
+// logParseErrors summarizes the malformed and partly supported entries found
+// in a feed
+func logParseErrors(feed calendar.Feed, parseErrors []*calendar.ParseError) {
+	var malformed, warnings []string
+	for _, perr := range parseErrors {
+		line := fmt.Sprintf("%d (%s)", perr.Line, perr.Property)
+		if perr.Warning {
+			warnings = append(warnings, line)
+		} else {
+			malformed = append(malformed, line)
+		}
+		logger.Debug("%v: %s", perr, perr.Raw)
+	}
+	if len(malformed) > 0 {
+		logger.Error("Feed %s: %d malformed entries at lines %s", feed.ID, len(malformed), strings.Join(malformed, ", "))
+	}
+	if len(warnings) > 0 {
+		logger.Info("Feed %s: %d partly supported entries at lines %s", feed.ID, len(warnings), strings.Join(warnings, ", "))
+	}
+}
+
 func loadConfig() (*Config, error) {
 	// Required environment variables
 	githubRepo := os.Getenv("GITHUB_REPO")
@@ -212,18 +468,34 @@ func loadConfig() (*Config, error) {
 	}
 
 	icsFeeds := os.Getenv("ICS_FEEDS")
-	if icsFeeds == "" {
+	feedsConfig := os.Getenv("FEEDS_CONFIG")
+	if icsFeeds == "" && feedsConfig == "" {
 		return nil, fmt.Errorf("ICS_FEEDS environment variable is required")
 	}
 
 	config := &Config{
-		GithubRepo:     githubRepo,
-		GithubBranch:   "main",
-		ICSFeeds:       strings.Split(icsFeeds, ","),
-		TimeZone:       "UTC",
-		SyncSchedule:   "*/30 * * * *",
-		RepoDirectory:  "/app/repo",
-		ScheduleMonths: 3, // Default to 3 months
+		GithubRepo:        githubRepo,
+		GithubBranch:      "main",
+		ICSFeeds:          splitList(icsFeeds),
+		TimeZone:          "UTC",
+		SyncSchedule:      "*/30 * * * *",
+		RepoDirectory:     "/app/repo",
+		CacheDir:          "/app/cache",
+		ScheduleMonths:    3, // Default to 3 months
+		AllDayEvents:      string(calendar.AllDayBusy),
+		WorkingHours:      "mon-fri 09:00-17:00",
+		SlotMinutes:       30,
+		ParseMode:         string(calendar.ParseLenient),
+		FeedMaxStaleness:  24 * time.Hour,
+		FeedTimeout:       30 * time.Second,
+		FetchMaxAttempts:  3,
+		FetchRetryBackoff: time.Second,
+		FetchConcurrency:  4,
+		HostConcurrency:   2,
+		FeedMaxSizeMB:     50,
+		ShrinkThreshold:   50,
+		ShrinkGrace:       24 * time.Hour,
+		ShrinkMinEvents:   1,
 	}
 
 	// Load optional environment variables
@@ -249,5 +521,216 @@ func loadConfig() (*Config, error) {
 		}
 	}
 
+	if mode := os.Getenv("ALL_DAY_EVENTS"); mode != "" {
+		switch calendar.AllDayMode(mode) {
+		case calendar.AllDayBusy, calendar.AllDayAnnotate:
+			config.AllDayEvents = mode
+		default:
+			return nil, fmt.Errorf("ALL_DAY_EVENTS must be %q or %q", calendar.AllDayBusy, calendar.AllDayAnnotate)
+		}
+	}
+
+	if hours := os.Getenv("WORKING_HOURS"); hours != "" {
+		if _, err := calendar.ParseWorkingHours(hours); err != nil {
+			return nil, fmt.Errorf("WORKING_HOURS: %w", err)
+		}
+		config.WorkingHours = hours
+	}
+
+	if minutes := os.Getenv("SLOT_MINUTES"); minutes != "" {
+		// Slots must divide an hour so that rows line up on the hour
+		m, err := strconv.Atoi(minutes)
+		if err != nil || m <= 0 || 60%m != 0 {
+			return nil, fmt.Errorf("SLOT_MINUTES must divide 60, such as 15, 30 or 60")
+		}
+		config.SlotMinutes = m
+	}
+
+	if emails := os.Getenv("OWNER_EMAILS"); emails != "" {
+		config.OwnerEmails = strings.Split(emails, ",")
+	}
+
+	if heuristic := os.Getenv("TITLE_BUSY_HEURISTIC"); heuristic != "" {
+		enabled, err := strconv.ParseBool(heuristic)
+		if err != nil {
+			return nil, fmt.Errorf("TITLE_BUSY_HEURISTIC must be true or false: %w", err)
+		}
+		config.TitleBusyHeuristic = enabled
+	}
+
+	if todos := os.Getenv("INCLUDE_TODOS"); todos != "" {
+		include, err := strconv.ParseBool(todos)
+		if err != nil {
+			return nil, fmt.Errorf("INCLUDE_TODOS must be true or false: %w", err)
+		}
+		config.IncludeTodos = include
+	}
+
+	if mode := os.Getenv("PARSE_MODE"); mode != "" {
+		switch calendar.ParseMode(mode) {
+		case calendar.ParseLenient, calendar.ParseStrict:
+			config.ParseMode = mode
+		default:
+			return nil, fmt.Errorf("PARSE_MODE must be %q or %q", calendar.ParseLenient, calendar.ParseStrict)
+		}
+	}
+
+	if dir := os.Getenv("CACHE_DIR"); dir != "" {
+		config.CacheDir = dir
+	}
+
+	if staleness := os.Getenv("FEED_MAX_STALENESS"); staleness != "" {
+		d, err := time.ParseDuration(staleness)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("FEED_MAX_STALENESS must be a duration such as 24h, or 0 for no limit")
+		}
+		config.FeedMaxStaleness = d
+	}
+
+	if timeout := os.Getenv("FEED_TIMEOUT"); timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("FEED_TIMEOUT must be a duration such as 30s, or 0 for no limit")
+		}
+		config.FeedTimeout = d
+	}
+
+	if attempts := os.Getenv("FETCH_MAX_ATTEMPTS"); attempts != "" {
+		n, err := strconv.Atoi(attempts)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("FETCH_MAX_ATTEMPTS must be a positive number")
+		}
+		config.FetchMaxAttempts = n
+	}
+
+	if backoff := os.Getenv("FETCH_RETRY_BACKOFF"); backoff != "" {
+		d, err := time.ParseDuration(backoff)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("FETCH_RETRY_BACKOFF must be a duration such as 1s")
+		}
+		config.FetchRetryBackoff = d
+	}
+
+	if concurrency := os.Getenv("FETCH_CONCURRENCY"); concurrency != "" {
+		n, err := strconv.Atoi(concurrency)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("FETCH_CONCURRENCY must be a positive number")
+		}
+		config.FetchConcurrency = n
+	}
+
+	if concurrency := os.Getenv("FETCH_HOST_CONCURRENCY"); concurrency != "" {
+		n, err := strconv.Atoi(concurrency)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("FETCH_HOST_CONCURRENCY must be a number, or 0 for no limit")
+		}
+		config.HostConcurrency = n
+	}
+
+	if size := os.Getenv("FEED_MAX_SIZE_MB"); size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("FEED_MAX_SIZE_MB must be a number, or 0 for no limit")
+		}
+		config.FeedMaxSizeMB = n
+	}
+
+	config.FeedCountsFile = os.Getenv("FEED_COUNTS_FILE")
+	if config.FeedCountsFile == "" && config.CacheDir != "" {
+		config.FeedCountsFile = filepath.Join(config.CacheDir, "feed-counts.json")
+	}
+
+	if threshold := os.Getenv("FEED_SHRINK_THRESHOLD"); threshold != "" {
+		n, err := strconv.Atoi(threshold)
+		if err != nil || n < 0 || n > 100 {
+			return nil, fmt.Errorf("FEED_SHRINK_THRESHOLD must be a percentage, or 0 to disable the check")
+		}
+		config.ShrinkThreshold = n
+	}
+
+	if grace := os.Getenv("FEED_SHRINK_GRACE"); grace != "" {
+		d, err := time.ParseDuration(grace)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("FEED_SHRINK_GRACE must be a duration such as 24h, or 0 to never accept a drop")
+		}
+		config.ShrinkGrace = d
+	}
+
+	if minEvents := os.Getenv("FEED_SHRINK_MIN_EVENTS"); minEvents != "" {
+		n, err := strconv.Atoi(minEvents)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("FEED_SHRINK_MIN_EVENTS must be a positive number of events")
+		}
+		config.ShrinkMinEvents = n
+	}
+
+	config.RunReport = os.Getenv("RUN_REPORT")
+	config.FetchProxy = os.Getenv("FETCH_PROXY")
+	config.FetchCAFiles = splitList(os.Getenv("FETCH_CA_FILES"))
+
+	if feedsConfig != "" {
+		feeds, err := loadFeedsConfig(feedsConfig)
+		if err != nil {
+			return nil, err
+		}
+		config.Feeds = feeds
+	}
+
 	return config, nil
 }
+
+// loadFeedsConfig reads the JSON list of feeds with their own settings
+func loadFeedsConfig(path string) ([]FeedConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading FEEDS_CONFIG: %w", err)
+	}
+
+	var feeds []FeedConfig
+	if err := json.Unmarshal(data, &feeds); err != nil {
+		return nil, fmt.Errorf("parsing FEEDS_CONFIG: %w", err)
+	}
+	for i, feed := range feeds {
+		if feed.Source == "" {
+			return nil, fmt.Errorf("FEEDS_CONFIG entry %d has no source", i+1)
+		}
+		if feed.Timeout != "" {
+			if d, err := time.ParseDuration(feed.Timeout); err != nil || d < 0 {
+				return nil, fmt.Errorf("FEEDS_CONFIG entry %d has an invalid timeout %q", i+1, feed.Timeout)
+			}
+		}
+		switch feed.Type {
+		case "", "ics":
+		case "caldav":
+			if !strings.HasPrefix(feed.Source, "http") {
+				return nil, fmt.Errorf("FEEDS_CONFIG entry %d: CalDAV sources must be URLs", i+1)
+			}
+		default:
+			return nil, fmt.Errorf("FEEDS_CONFIG entry %d has an unknown type %q", i+1, feed.Type)
+		}
+		if feed.Auth != nil && feed.Auth.Username != "" && feed.Auth.Token != "" {
+			return nil, fmt.Errorf("FEEDS_CONFIG entry %d has both a username and a token", i+1)
+		}
+		if tc := feed.TLS; tc != nil {
+			if (tc.Cert == "") != (tc.Key == "") {
+				return nil, fmt.Errorf("FEEDS_CONFIG entry %d needs both a client certificate and its key", i+1)
+			}
+			if tc.PinSHA256 != "" || tc.InsecureSkipVerify {
+				u, err := url.Parse(feed.Source)
+				relaxed := &calendar.TLSConfig{Host: tc.Host}
+				if err != nil || !relaxed.AllowsRelaxed(u.Hostname()) {
+					return nil, fmt.Errorf("FEEDS_CONFIG entry %d: certificates can only be pinned or left unverified for localhost or the host named in \"tls\"", i+1)
+				}
+			}
+		}
+	}
+	return feeds, nil
+}
+
+// splitList splits a comma-separated value, or returns nil when it is empty
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
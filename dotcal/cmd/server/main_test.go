@@ -80,6 +80,29 @@ func TestLoadConfig(t *testing.T) {
 			"SYNC_SCHEDULE",
 			"REPO_DIRECTORY",
 			"SCHEDULE_MONTHS",
+			"ALL_DAY_EVENTS",
+			"OWNER_EMAILS",
+			"TITLE_BUSY_HEURISTIC",
+			"INCLUDE_TODOS",
+			"PARSE_MODE",
+			"CACHE_DIR",
+			"FEED_MAX_STALENESS",
+			"FEED_TIMEOUT",
+			"FETCH_MAX_ATTEMPTS",
+			"FETCH_RETRY_BACKOFF",
+			"FEEDS_CONFIG",
+			"FETCH_CONCURRENCY",
+			"FETCH_HOST_CONCURRENCY",
+			"FEED_MAX_SIZE_MB",
+			"FEED_COUNTS_FILE",
+			"FEED_SHRINK_THRESHOLD",
+			"FEED_SHRINK_GRACE",
+			"FEED_SHRINK_MIN_EVENTS",
+			"RUN_REPORT",
+			"FETCH_PROXY",
+			"FETCH_CA_FILES",
+			"WORKING_HOURS",
+			"SLOT_MINUTES",
 		}
 		for _, v := range vars {
 			os.Unsetenv(v)
@@ -126,6 +149,49 @@ func TestLoadConfig(t *testing.T) {
 		if config.ScheduleMonths != 3 {
 			t.Errorf("Expected default schedule months 3, got %d", config.ScheduleMonths)
 		}
+		if config.AllDayEvents != "busy" {
+			t.Errorf("Expected default all-day mode 'busy', got %s", config.AllDayEvents)
+		}
+		if config.WorkingHours != "mon-fri 09:00-17:00" {
+			t.Errorf("Expected default working hours 9 to 5 on weekdays, got %s", config.WorkingHours)
+		}
+		if config.SlotMinutes != 30 {
+			t.Errorf("Expected default slot minutes 30, got %d", config.SlotMinutes)
+		}
+		if config.TitleBusyHeuristic {
+			t.Error("Expected title busy heuristic to be off by default")
+		}
+		if config.IncludeTodos {
+			t.Error("Expected to-dos to be excluded by default")
+		}
+		if config.ParseMode != "lenient" {
+			t.Errorf("Expected default parse mode 'lenient', got %s", config.ParseMode)
+		}
+		if config.CacheDir != "/app/cache" {
+			t.Errorf("Expected default cache directory /app/cache, got %s", config.CacheDir)
+		}
+		if config.FeedMaxStaleness != 24*time.Hour {
+			t.Errorf("Expected default max staleness 24h, got %v", config.FeedMaxStaleness)
+		}
+		if config.FeedTimeout != 30*time.Second {
+			t.Errorf("Expected default feed timeout 30s, got %v", config.FeedTimeout)
+		}
+		if config.FetchMaxAttempts != 3 {
+			t.Errorf("Expected default max attempts 3, got %d", config.FetchMaxAttempts)
+		}
+		if config.FetchConcurrency != 4 || config.HostConcurrency != 2 {
+			t.Errorf("Expected default concurrency 4 with 2 per host, got %d and %d", config.FetchConcurrency, config.HostConcurrency)
+		}
+		if config.FeedMaxSizeMB != 50 {
+			t.Errorf("Expected default feed size limit 50 MB, got %d", config.FeedMaxSizeMB)
+		}
+		if config.FeedCountsFile != "/app/cache/feed-counts.json" || config.ShrinkThreshold != 50 || config.ShrinkGrace != 24*time.Hour {
+			t.Errorf("Expected counts in the cache directory with a 50%% threshold and 24h grace, got %q, %d and %v",
+				config.FeedCountsFile, config.ShrinkThreshold, config.ShrinkGrace)
+		}
+		if config.ShrinkMinEvents != 1 {
+			t.Errorf("Expected every feed with events to be guarded by default, got a minimum of %d", config.ShrinkMinEvents)
+		}
 	})
 
 	t.Run("optional environment variables", func(t *testing.T) {
@@ -134,13 +200,34 @@ func TestLoadConfig(t *testing.T) {
 
 		// Set all variables
 		env := map[string]string{
-			"GITHUB_REPO":     "git@github.com:user/repo.git",
-			"ICS_FEEDS":       "feed1.ics,feed2.ics",
-			"GITHUB_BRANCH":   "develop",
-			"TIMEZONE":        "America/New_York",
-			"SYNC_SCHEDULE":   "0 * * * *",
-			"REPO_DIRECTORY":  "/custom/path",
-			"SCHEDULE_MONTHS": "6",
+			"GITHUB_REPO":            "git@github.com:user/repo.git",
+			"ICS_FEEDS":              "feed1.ics,feed2.ics",
+			"GITHUB_BRANCH":          "develop",
+			"TIMEZONE":               "America/New_York",
+			"SYNC_SCHEDULE":          "0 * * * *",
+			"REPO_DIRECTORY":         "/custom/path",
+			"SCHEDULE_MONTHS":        "6",
+			"ALL_DAY_EVENTS":         "annotate",
+			"OWNER_EMAILS":           "me@example.com,me@work.example.com",
+			"TITLE_BUSY_HEURISTIC":   "true",
+			"INCLUDE_TODOS":          "true",
+			"PARSE_MODE":             "strict",
+			"CACHE_DIR":              "/custom/cache",
+			"FEED_MAX_STALENESS":     "6h",
+			"FEED_TIMEOUT":           "1m",
+			"FETCH_MAX_ATTEMPTS":     "5",
+			"FETCH_RETRY_BACKOFF":    "500ms",
+			"FETCH_CONCURRENCY":      "8",
+			"FETCH_HOST_CONCURRENCY": "0",
+			"FEED_MAX_SIZE_MB":       "5",
+			"FEED_SHRINK_THRESHOLD":  "80",
+			"FEED_SHRINK_GRACE":      "72h",
+			"FEED_SHRINK_MIN_EVENTS": "5",
+			"RUN_REPORT":             "/custom/report.json",
+			"FETCH_PROXY":            "http://proxy.example.com:3128",
+			"FETCH_CA_FILES":         "/certs/corp.pem,/certs/lab.pem",
+			"WORKING_HOURS":          "mon-thu 07:00-15:00",
+			"SLOT_MINUTES":           "15",
 		}
 
 		for k, v := range env {
@@ -153,13 +240,35 @@ func TestLoadConfig(t *testing.T) {
 		}
 
 		expected := &Config{
-			GithubRepo:     "git@github.com:user/repo.git",
-			GithubBranch:   "develop",
-			ICSFeeds:       []string{"feed1.ics", "feed2.ics"},
-			TimeZone:       "America/New_York",
-			SyncSchedule:   "0 * * * *",
-			RepoDirectory:  "/custom/path",
-			ScheduleMonths: 6,
+			GithubRepo:         "git@github.com:user/repo.git",
+			GithubBranch:       "develop",
+			ICSFeeds:           []string{"feed1.ics", "feed2.ics"},
+			TimeZone:           "America/New_York",
+			SyncSchedule:       "0 * * * *",
+			RepoDirectory:      "/custom/path",
+			ScheduleMonths:     6,
+			AllDayEvents:       "annotate",
+			WorkingHours:       "mon-thu 07:00-15:00",
+			SlotMinutes:        15,
+			OwnerEmails:        []string{"me@example.com", "me@work.example.com"},
+			TitleBusyHeuristic: true,
+			IncludeTodos:       true,
+			ParseMode:          "strict",
+			CacheDir:           "/custom/cache",
+			FeedMaxStaleness:   6 * time.Hour,
+			FeedTimeout:        time.Minute,
+			FetchMaxAttempts:   5,
+			FetchRetryBackoff:  500 * time.Millisecond,
+			FetchConcurrency:   8,
+			HostConcurrency:    0,
+			FeedMaxSizeMB:      5,
+			FeedCountsFile:     "/custom/cache/feed-counts.json",
+			ShrinkThreshold:    80,
+			ShrinkGrace:        72 * time.Hour,
+			ShrinkMinEvents:    5,
+			RunReport:          "/custom/report.json",
+			FetchProxy:         "http://proxy.example.com:3128",
+			FetchCAFiles:       []string{"/certs/corp.pem", "/certs/lab.pem"},
 		}
 
 		if !reflect.DeepEqual(config, expected) {
@@ -186,6 +295,332 @@ func TestLoadConfig(t *testing.T) {
 		}
 	})
 
+	t.Run("invalid all-day mode", func(t *testing.T) {
+		cleanup()
+		defer cleanup()
+
+		os.Setenv("GITHUB_REPO", "git@github.com:user/repo.git")
+		os.Setenv("ICS_FEEDS", "feed1.ics")
+		os.Setenv("ALL_DAY_EVENTS", "sometimes")
+
+		if _, err := loadConfig(); err == nil {
+			t.Error("Expected error for invalid ALL_DAY_EVENTS")
+		}
+	})
+
+	t.Run("invalid parse mode", func(t *testing.T) {
+		cleanup()
+		defer cleanup()
+
+		os.Setenv("GITHUB_REPO", "git@github.com:user/repo.git")
+		os.Setenv("ICS_FEEDS", "feed1.ics")
+		os.Setenv("PARSE_MODE", "pedantic")
+
+		if _, err := loadConfig(); err == nil {
+			t.Error("Expected error for invalid PARSE_MODE")
+		}
+	})
+
+	t.Run("feeds config", func(t *testing.T) {
+		cleanup()
+		defer cleanup()
+
+		path := filepath.Join(t.TempDir(), "feeds.json")
+		feeds := `[{"source": "https://example.com/slow.ics", "timeout": "90s"}, {"source": "/data/local.ics"}, {"source": "https://dav.example.com/", "type": "caldav"}]`
+		if err := os.WriteFile(path, []byte(feeds), 0644); err != nil {
+			t.Fatal(err)
+		}
+		os.Setenv("GITHUB_REPO", "git@github.com:user/repo.git")
+		os.Setenv("FEEDS_CONFIG", path)
+
+		config, err := loadConfig()
+		if err != nil {
+			t.Fatalf("Expected FEEDS_CONFIG to replace ICS_FEEDS, got error: %v", err)
+		}
+		expected := []FeedConfig{
+			{Source: "https://example.com/slow.ics", Timeout: "90s"},
+			{Source: "/data/local.ics"},
+			{Source: "https://dav.example.com/", Type: "caldav"},
+		}
+		if !reflect.DeepEqual(config.Feeds, expected) {
+			t.Errorf("Expected %+v, got %+v", expected, config.Feeds)
+		}
+
+		config.ICSFeeds = []string{"https://example.com/team.ics", "webcal://example.com/holidays.ics"}
+		built, err := buildFeeds(config, time.UTC)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(built) != 5 {
+			t.Fatalf("Expected 5 feeds, got %d", len(built))
+		}
+		if !built[1].IsURL {
+			t.Errorf("Expected a webcal link to be a URL, got %+v", built[1])
+		}
+		if built[0].Source != "https://example.com/team.ics" || built[0].Timeout != 0 {
+			t.Errorf("Expected ICS_FEEDS entries first with the default timeout, got %+v", built[0])
+		}
+		if built[2].ID != "feed-3" || !built[2].IsURL || built[2].Timeout != 90*time.Second {
+			t.Errorf("Expected the slow feed with its own timeout, got %+v", built[2])
+		}
+		if built[3].IsURL {
+			t.Errorf("Expected a local file, got %+v", built[3])
+		}
+		if built[4].Type != calendar.FeedCalDAV {
+			t.Errorf("Expected a CalDAV feed, got %+v", built[4])
+		}
+	})
+
+	t.Run("feed credentials", func(t *testing.T) {
+		cleanup()
+		defer cleanup()
+
+		dir := t.TempDir()
+		passwordFile := filepath.Join(dir, "password")
+		if err := os.WriteFile(passwordFile, []byte("s3cret\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, "feeds.json")
+		feeds := `[
+			{"source": "https://dav.example.com/a.ics", "auth": {"username": "me", "password": "file:` + passwordFile + `"}},
+			{"source": "https://api.example.com/b.ics", "auth": {"token": "env:TEST_FEED_TOKEN", "headers": {"X-Team": "core"}}}
+		]`
+		if err := os.WriteFile(path, []byte(feeds), 0644); err != nil {
+			t.Fatal(err)
+		}
+		os.Setenv("GITHUB_REPO", "git@github.com:user/repo.git")
+		os.Setenv("FEEDS_CONFIG", path)
+		os.Setenv("TEST_FEED_TOKEN", "t0ken")
+		defer os.Unsetenv("TEST_FEED_TOKEN")
+
+		config, err := loadConfig()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		built, err := buildFeeds(config, time.UTC)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		basic := built[0].Auth
+		if basic == nil || basic.Username != "me" || basic.Password != "s3cret" {
+			t.Errorf("Expected basic credentials read from the file, got %+v", basic)
+		}
+		bearer := built[1].Auth
+		if bearer == nil || bearer.Token != "t0ken" || bearer.Headers["X-Team"] != "core" {
+			t.Errorf("Expected a token from the environment and a header, got %+v", bearer)
+		}
+
+		os.Unsetenv("TEST_FEED_TOKEN")
+		if _, err := buildFeeds(config, time.UTC); err == nil {
+			t.Error("Expected error for a missing secret")
+		}
+	})
+
+	t.Run("invalid feeds config", func(t *testing.T) {
+		cleanup()
+		defer cleanup()
+
+		path := filepath.Join(t.TempDir(), "feeds.json")
+		if err := os.WriteFile(path, []byte(`[{"source": "a.ics", "timeout": "soon"}]`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		os.Setenv("GITHUB_REPO", "git@github.com:user/repo.git")
+		os.Setenv("FEEDS_CONFIG", path)
+
+		if _, err := loadConfig(); err == nil {
+			t.Error("Expected error for invalid feed timeout")
+		}
+	})
+
+	t.Run("feed TLS settings", func(t *testing.T) {
+		cleanup()
+		defer cleanup()
+
+		tests := []struct {
+			name    string
+			feeds   string
+			wantErr bool
+		}{
+			{
+				name:  "client certificate",
+				feeds: `[{"source": "https://exchange.corp.example.com/cal.ics", "tls": {"cert": "/certs/me.pem", "key": "/certs/me-key.pem"}}]`,
+			},
+			{
+				name:  "pinned local host",
+				feeds: `[{"source": "https://localhost:8443/cal.ics", "tls": {"pinSHA256": "ab:cd"}}]`,
+			},
+			{
+				name:  "pinned named host",
+				feeds: `[{"source": "https://nas.local:8443/cal.ics", "tls": {"pinSHA256": "ab:cd", "host": "nas.local"}}]`,
+			},
+			{
+				name:    "pinned network host not named",
+				feeds:   `[{"source": "https://192.168.1.20/cal.ics", "tls": {"pinSHA256": "ab:cd"}}]`,
+				wantErr: true,
+			},
+			{
+				name:    "certificate without key",
+				feeds:   `[{"source": "https://exchange.corp.example.com/cal.ics", "tls": {"cert": "/certs/me.pem"}}]`,
+				wantErr: true,
+			},
+			{
+				name:    "unverified remote host",
+				feeds:   `[{"source": "https://calendar.example.com/cal.ics", "tls": {"insecureSkipVerify": true}}]`,
+				wantErr: true,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				path := filepath.Join(t.TempDir(), "feeds.json")
+				if err := os.WriteFile(path, []byte(tt.feeds), 0644); err != nil {
+					t.Fatal(err)
+				}
+				os.Setenv("GITHUB_REPO", "git@github.com:user/repo.git")
+				os.Setenv("FEEDS_CONFIG", path)
+
+				config, err := loadConfig()
+				if tt.wantErr {
+					if err == nil {
+						t.Error("Expected error for invalid TLS settings")
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				built, err := buildFeeds(config, time.UTC)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				want := config.Feeds[0].TLS
+				got := built[0].TLS
+				if got == nil || got.CertFile != want.Cert || got.KeyFile != want.Key || got.PinSHA256 != want.PinSHA256 {
+					t.Errorf("Expected TLS settings %+v, got %+v", want, got)
+				}
+			})
+		}
+	})
+
+	t.Run("invalid working hours", func(t *testing.T) {
+		cleanup()
+		defer cleanup()
+
+		os.Setenv("GITHUB_REPO", "git@github.com:user/repo.git")
+		os.Setenv("ICS_FEEDS", "feed1.ics")
+		os.Setenv("WORKING_HOURS", "mon-fri 9-5")
+
+		if _, err := loadConfig(); err == nil {
+			t.Error("Expected error for invalid WORKING_HOURS")
+		}
+	})
+
+	t.Run("invalid slot minutes", func(t *testing.T) {
+		for _, minutes := range []string{"0", "45", "90", "half"} {
+			cleanup()
+			os.Setenv("GITHUB_REPO", "git@github.com:user/repo.git")
+			os.Setenv("ICS_FEEDS", "feed1.ics")
+			os.Setenv("SLOT_MINUTES", minutes)
+
+			if _, err := loadConfig(); err == nil {
+				t.Errorf("Expected error for SLOT_MINUTES %s", minutes)
+			}
+		}
+		cleanup()
+	})
+
+	t.Run("invalid fetch concurrency", func(t *testing.T) {
+		cleanup()
+		defer cleanup()
+
+		os.Setenv("GITHUB_REPO", "git@github.com:user/repo.git")
+		os.Setenv("ICS_FEEDS", "feed1.ics")
+		os.Setenv("FETCH_CONCURRENCY", "none")
+
+		if _, err := loadConfig(); err == nil {
+			t.Error("Expected error for invalid FETCH_CONCURRENCY")
+		}
+	})
+
+	t.Run("invalid feed max size", func(t *testing.T) {
+		cleanup()
+		defer cleanup()
+
+		os.Setenv("GITHUB_REPO", "git@github.com:user/repo.git")
+		os.Setenv("ICS_FEEDS", "feed1.ics")
+		os.Setenv("FEED_MAX_SIZE_MB", "-1")
+
+		if _, err := loadConfig(); err == nil {
+			t.Error("Expected error for invalid FEED_MAX_SIZE_MB")
+		}
+	})
+
+	t.Run("invalid feed shrink threshold", func(t *testing.T) {
+		cleanup()
+		defer cleanup()
+
+		os.Setenv("GITHUB_REPO", "git@github.com:user/repo.git")
+		os.Setenv("ICS_FEEDS", "feed1.ics")
+		os.Setenv("FEED_SHRINK_THRESHOLD", "150")
+
+		if _, err := loadConfig(); err == nil {
+			t.Error("Expected error for invalid FEED_SHRINK_THRESHOLD")
+		}
+	})
+
+	t.Run("invalid feed shrink minimum", func(t *testing.T) {
+		cleanup()
+		defer cleanup()
+
+		os.Setenv("GITHUB_REPO", "git@github.com:user/repo.git")
+		os.Setenv("ICS_FEEDS", "feed1.ics")
+		os.Setenv("FEED_SHRINK_MIN_EVENTS", "0")
+
+		if _, err := loadConfig(); err == nil {
+			t.Error("Expected error for invalid FEED_SHRINK_MIN_EVENTS")
+		}
+	})
+
+	t.Run("invalid fetch max attempts", func(t *testing.T) {
+		cleanup()
+		defer cleanup()
+
+		os.Setenv("GITHUB_REPO", "git@github.com:user/repo.git")
+		os.Setenv("ICS_FEEDS", "feed1.ics")
+		os.Setenv("FETCH_MAX_ATTEMPTS", "0")
+
+		if _, err := loadConfig(); err == nil {
+			t.Error("Expected error for invalid FETCH_MAX_ATTEMPTS")
+		}
+	})
+
+	t.Run("invalid feed max staleness", func(t *testing.T) {
+		cleanup()
+		defer cleanup()
+
+		os.Setenv("GITHUB_REPO", "git@github.com:user/repo.git")
+		os.Setenv("ICS_FEEDS", "feed1.ics")
+		os.Setenv("FEED_MAX_STALENESS", "a while")
+
+		if _, err := loadConfig(); err == nil {
+			t.Error("Expected error for invalid FEED_MAX_STALENESS")
+		}
+	})
+
+	t.Run("invalid title busy heuristic", func(t *testing.T) {
+		cleanup()
+		defer cleanup()
+
+		os.Setenv("GITHUB_REPO", "git@github.com:user/repo.git")
+		os.Setenv("ICS_FEEDS", "feed1.ics")
+		os.Setenv("TITLE_BUSY_HEURISTIC", "maybe")
+
+		if _, err := loadConfig(); err == nil {
+			t.Error("Expected error for invalid TITLE_BUSY_HEURISTIC")
+		}
+	})
+
 	t.Run("multiple ICS feeds", func(t *testing.T) {
 		cleanup()
 		defer cleanup()
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/zach/dotcal/internal/calendar"
+)
+
+// FeedStatus is the outcome of fetching a feed in a run
+type FeedStatus string
+
+const (
+	FeedOK     FeedStatus = "ok"
+	FeedStale  FeedStatus = "stale"  // A cached copy was used
+	FeedFailed FeedStatus = "failed" // The feed was left out
+	FeedShrunk FeedStatus = "shrunk" // The feed lost too many events and had no cached copy
+)
+
+// RunReport summarizes a sync run, written to RUN_REPORT as JSON
+type RunReport struct {
+	StartedAt time.Time    `json:"startedAt"`
+	Published bool         `json:"published"`
+	Reason    string       `json:"reason,omitempty"` // Why the run did not publish
+	Feeds     []FeedReport `json:"feeds"`
+}
+
+// FeedReport is the outcome of one feed in a run
+type FeedReport struct {
+	ID          string     `json:"id"`
+	Source      string     `json:"source"` // Redacted
+	Status      FeedStatus `json:"status"`
+	Events      int        `json:"events"`
+	ParseErrors int        `json:"parseErrors,omitempty"`
+	Warnings    int        `json:"warnings,omitempty"` // Partly supported entries
+	FetchedAt   time.Time  `json:"fetchedAt,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	// Event count of the last good fetch, for feeds that shrank
+	PreviousEvents int `json:"previousEvents,omitempty"`
+}
+
+// addFeed records the outcome of a fetched feed
+func (r *RunReport) addFeed(result calendar.FeedResult) {
+	feed := FeedReport{
+		ID:        result.Feed.ID,
+		Source:    calendar.RedactURL(result.Feed.Source),
+		Status:    FeedOK,
+		Events:    len(result.Events),
+		FetchedAt: result.FetchedAt,
+	}
+	for _, perr := range result.ParseErrors {
+		if perr.Warning {
+			feed.Warnings++
+		} else {
+			feed.ParseErrors++
+		}
+	}
+
+	err := result.Err
+	switch {
+	case err != nil:
+		feed.Status = FeedFailed
+	case result.Stale:
+		feed.Status = FeedStale
+		err = result.FetchErr
+	}
+	if err != nil {
+		feed.Error = err.Error()
+	}
+	var serr *calendar.ShrinkError
+	if errors.As(err, &serr) {
+		feed.PreviousEvents = serr.Previous
+		if result.Err != nil {
+			feed.Status = FeedShrunk
+		}
+	}
+
+	r.Feeds = append(r.Feeds, feed)
+}
+
+// write saves the report to path, doing nothing when path is empty
+func (r *RunReport) write(path string) error {
+	if path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to write run report: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write run report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package calendar
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Auth holds the credentials sent with every request for a feed
+type Auth struct {
+	Username string // HTTP Basic credentials, used when Username is set
+	Password string
+	Token    string            // Bearer token
+	Headers  map[string]string // Additional request headers, such as API keys
+}
+
+// apply adds the credentials to a request
+func (a *Auth) apply(req *http.Request) {
+	if a == nil {
+		return
+	}
+	for name, value := range a.Headers {
+		req.Header.Set(name, value)
+	}
+	switch {
+	case a.Username != "":
+		req.SetBasicAuth(a.Username, a.Password)
+	case a.Token != "":
+		req.Header.Set("Authorization", "Bearer "+a.Token)
+	}
+}
+
+// redirect removes the feed's headers from a request redirected to another
+// host. The HTTP client drops Authorization itself, but copies every other
+// header, such as an API key, to the new host.
+func (a *Auth) redirect(req *http.Request, via []*http.Request) {
+	if a == nil || len(via) == 0 || strings.EqualFold(req.URL.Host, via[0].URL.Host) {
+		return
+	}
+	for name := range a.Headers {
+		req.Header.Del(name)
+	}
+}
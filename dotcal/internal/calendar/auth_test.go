@@ -0,0 +1,98 @@
+package calendar
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetchWithAuth(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte("BEGIN:VCALENDAR\nEND:VCALENDAR"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		auth     *Auth
+		expected map[string]string
+	}{
+		{
+			name:     "no auth",
+			auth:     nil,
+			expected: map[string]string{"Authorization": ""},
+		},
+		{
+			name:     "basic",
+			auth:     &Auth{Username: "me", Password: "secret"},
+			expected: map[string]string{"Authorization": "Basic bWU6c2VjcmV0"},
+		},
+		{
+			name:     "bearer",
+			auth:     &Auth{Token: "abc123"},
+			expected: map[string]string{"Authorization": "Bearer abc123"},
+		},
+		{
+			name:     "custom headers",
+			auth:     &Auth{Headers: map[string]string{"X-Api-Key": "k1"}},
+			expected: map[string]string{"X-Api-Key": "k1", "Authorization": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newTestFetcher().Fetch(Feed{Source: server.URL, IsURL: true, Auth: tt.auth}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			for name, value := range tt.expected {
+				if got.Get(name) != value {
+					t.Errorf("Expected %s %q, got %q", name, value, got.Get(name))
+				}
+			}
+		})
+	}
+}
+
+func TestFetchWithAuthRedirect(t *testing.T) {
+	var got http.Header
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte("BEGIN:VCALENDAR\nEND:VCALENDAR"))
+	}))
+	defer other.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moved":
+			http.Redirect(w, r, "/calendar.ics", http.StatusFound)
+		case "/calendar.ics":
+			got = r.Header.Clone()
+			w.Write([]byte("BEGIN:VCALENDAR\nEND:VCALENDAR"))
+		default:
+			http.Redirect(w, r, other.URL+"/calendar.ics", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	auth := &Auth{Token: "abc123", Headers: map[string]string{"X-Api-Key": "k1"}}
+
+	t.Run("same host keeps headers", func(t *testing.T) {
+		if _, err := newTestFetcher().Fetch(Feed{Source: server.URL + "/moved", IsURL: true, Auth: auth}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if got.Get("X-Api-Key") != "k1" {
+			t.Errorf("Expected X-Api-Key k1, got %q", got.Get("X-Api-Key"))
+		}
+	})
+
+	t.Run("other host gets no headers", func(t *testing.T) {
+		if _, err := newTestFetcher().Fetch(Feed{Source: server.URL + "/elsewhere", IsURL: true, Auth: auth}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if got.Get("X-Api-Key") != "" {
+			t.Errorf("Expected no X-Api-Key on the other host, got %q", got.Get("X-Api-Key"))
+		}
+	})
+}
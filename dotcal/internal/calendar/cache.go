@@ -0,0 +1,198 @@
+package calendar
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/zach/dotcal/internal/logger"
+)
+
+// CacheStatus reports how a feed's data was obtained
+type CacheStatus string
+
+const (
+	// CacheDisabled means no cache directory is configured or the feed is a
+	// local file
+	CacheDisabled CacheStatus = ""
+	// CacheMiss means the feed was downloaded and stored in the cache
+	CacheMiss CacheStatus = "miss"
+	// CacheHit means the server reported the feed unchanged and the cached
+	// copy was used
+	CacheHit CacheStatus = "hit"
+)
+
+// cacheEntry is the metadata stored next to a cached feed body
+type cacheEntry struct {
+	Source       string    `json:"source"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	ContentType  string    `json:"contentType,omitempty"`
+	FetchedAt    time.Time `json:"fetchedAt"`
+}
+
+// feedCache stores the last response of each feed in a directory. Files are
+// named after the SHA-256 of the feed's source so URLs with credentials do
+// not end up in file names.
+type feedCache struct {
+	dir string
+}
+
+// paths returns the body and metadata files for a source
+func (c *feedCache) paths(source string) (string, string) {
+	sum := sha256.Sum256([]byte(source))
+	key := hex.EncodeToString(sum[:])
+	return filepath.Join(c.dir, key+".body"), filepath.Join(c.dir, key+".json")
+}
+
+// load returns the metadata of a cached feed, or nil when there is no usable
+// copy
+func (c *feedCache) load(source string) *cacheEntry {
+	bodyPath, metaPath := c.paths(source)
+	data, err := os.ReadFile(metaPath)
+	if err != nil {
+		return nil
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Source != source {
+		logger.Debug("ignoring cache entry %s: %v", metaPath, err)
+		return nil
+	}
+	if _, err := os.Stat(bodyPath); err != nil {
+		return nil
+	}
+	return &entry
+}
+
+// open returns the cached body of a feed
+func (c *feedCache) open(source string) (io.ReadCloser, error) {
+	bodyPath, _ := c.paths(source)
+	file, err := os.Open(bodyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cached feed: %w", err)
+	}
+	return file, nil
+}
+
+// store wraps a response body so that it is written to the cache as it is
+// read. The entry is only committed once the body has been read completely.
+func (c *feedCache) store(entry cacheEntry, body io.ReadCloser) io.ReadCloser {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		logger.Error("Failed to create cache directory: %v", err)
+		return body
+	}
+	tmp, err := os.CreateTemp(c.dir, "feed-*.tmp")
+	if err != nil {
+		logger.Error("Failed to create cache file: %v", err)
+		return body
+	}
+	return &cachingBody{cache: c, entry: entry, body: body, tmp: tmp}
+}
+
+// put stores a complete body, such as the calendar objects of a CalDAV feed
+func (c *feedCache) put(entry cacheEntry, body []byte) error {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.dir, "feed-*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(body)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = c.commit(entry, tmp.Name())
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// commit moves a completely downloaded body into place and writes its
+// metadata
+func (c *feedCache) commit(entry cacheEntry, tmpPath string) error {
+	bodyPath, _ := c.paths(entry.Source)
+	if err := os.Rename(tmpPath, bodyPath); err != nil {
+		return err
+	}
+	return c.writeEntry(entry)
+}
+
+// writeEntry replaces the metadata of a cached feed
+func (c *feedCache) writeEntry(entry cacheEntry) error {
+	_, metaPath := c.paths(entry.Source)
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	tmpMeta := metaPath + ".tmp"
+	if err := os.WriteFile(tmpMeta, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpMeta, metaPath)
+}
+
+// cachingBody copies a response body into a temporary file while it is read
+type cachingBody struct {
+	cache *feedCache
+	entry cacheEntry
+	body  io.ReadCloser
+	tmp   *os.File // nil once writing has failed
+	eof   bool
+}
+
+func (b *cachingBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if n > 0 && b.tmp != nil {
+		if _, werr := b.tmp.Write(p[:n]); werr != nil {
+			logger.Error("Failed to write cache file: %v", werr)
+			b.discard()
+		}
+	}
+	if err == io.EOF {
+		b.eof = true
+	}
+	return n, err
+}
+
+func (b *cachingBody) Close() error {
+	err := b.body.Close()
+	if b.tmp == nil {
+		return err
+	}
+	if !b.eof {
+		// A partial body must not replace the previous copy
+		b.discard()
+		return err
+	}
+
+	if cerr := b.tmp.Close(); cerr != nil {
+		logger.Error("Failed to write cache file: %v", cerr)
+		os.Remove(b.tmp.Name())
+		return err
+	}
+	if cerr := b.cache.commit(b.entry, b.tmp.Name()); cerr != nil {
+		logger.Error("Failed to update feed cache: %v", cerr)
+		os.Remove(b.tmp.Name())
+	}
+	return err
+}
+
+// discard stops caching and removes the temporary file
+func (b *cachingBody) discard() {
+	if b.tmp == nil {
+		return
+	}
+	b.tmp.Close()
+	os.Remove(b.tmp.Name())
+	b.tmp = nil
+}
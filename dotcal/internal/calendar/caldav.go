@@ -0,0 +1,289 @@
+package calendar
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/zach/dotcal/internal/logger"
+)
+
+const (
+	davNS    = "DAV:"
+	caldavNS = "urn:ietf:params:xml:ns:caldav"
+)
+
+// propfindBody asks for the properties needed to find a user's calendars
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop>
+    <d:resourcetype/>
+    <d:current-user-principal/>
+    <c:calendar-home-set/>
+  </d:prop>
+</d:propfind>`
+
+// calendarQueryBody requests the calendar data of one component type,
+// with %s standing for the component name and an optional time-range
+const calendarQueryBody = `<?xml version="1.0" encoding="utf-8"?>
+<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop>
+    <c:calendar-data/>
+  </d:prop>
+  <c:filter>
+    <c:comp-filter name="VCALENDAR">
+      <c:comp-filter name="%s">%s</c:comp-filter>
+    </c:comp-filter>
+  </c:filter>
+</c:calendar-query>`
+
+// davResponse is a response element of a WebDAV multistatus body
+type davResponse struct {
+	Href     string `xml:"DAV: href"`
+	Propstat []struct {
+		Status string  `xml:"DAV: status"`
+		Prop   davProp `xml:"DAV: prop"`
+	} `xml:"DAV: propstat"`
+}
+
+// davProp holds the WebDAV and CalDAV properties dotcal asks for
+type davProp struct {
+	ResourceType struct {
+		Calendar *struct{} `xml:"urn:ietf:params:xml:ns:caldav calendar"`
+	} `xml:"DAV: resourcetype"`
+	CurrentUserPrincipal davHref `xml:"DAV: current-user-principal"`
+	CalendarHomeSet      davHref `xml:"urn:ietf:params:xml:ns:caldav calendar-home-set"`
+	CalendarData         string  `xml:"urn:ietf:params:xml:ns:caldav calendar-data"`
+}
+
+type davHref struct {
+	Href string `xml:"DAV: href"`
+}
+
+// prop returns the properties the server found; those it lacks are
+// reported in a propstat with a non-200 status
+func (r davResponse) prop() davProp {
+	for _, ps := range r.Propstat {
+		if strings.Contains(ps.Status, " 200 ") {
+			return ps.Prop
+		}
+	}
+	return davProp{}
+}
+
+// fetchCalDAV queries every calendar of a CalDAV feed for the components in
+// the parser's window. The calendar objects are cached together as one
+// stream, so the feed can fall back to them like a URL feed.
+func (f *Fetcher) fetchCalDAV(ctx context.Context, feed Feed, parser *Parser) (*FeedResult, error) {
+	calendars, err := f.discoverCalendars(ctx, feed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover calendars: %w", err)
+	}
+	if len(calendars) == 0 {
+		return nil, fmt.Errorf("no calendars found")
+	}
+
+	components := []string{"VEVENT"}
+	if parser.includeTodos {
+		components = append(components, "VTODO")
+	}
+
+	result := &FeedResult{Feed: feed, FetchedAt: time.Now()}
+	var data bytes.Buffer
+	for _, calendar := range calendars {
+		for _, component := range components {
+			if err := f.queryCalendar(ctx, feed, calendar, component, parser, result, &data); err != nil {
+				return nil, fmt.Errorf("failed to query calendar %s: %w", RedactURL(calendar), err)
+			}
+		}
+	}
+
+	// A collapsed feed must not replace the cached copy it falls back to
+	if _, err := f.checkCount(result, nil); err != nil {
+		return nil, err
+	}
+	if f.cache != nil {
+		entry := cacheEntry{Source: feed.Source, ContentType: "text/calendar", FetchedAt: result.FetchedAt}
+		if err := f.cache.put(entry, data.Bytes()); err != nil {
+			logger.Error("Failed to update feed cache: %v", err)
+		} else {
+			result.Cache = CacheMiss
+		}
+	}
+	return result, nil
+}
+
+// discoverCalendars returns the calendar collections of a feed, whose source
+// may be a calendar, a calendar home or a server or principal URL from which
+// the calendar home is found
+func (f *Fetcher) discoverCalendars(ctx context.Context, feed Feed) ([]string, error) {
+	target := feed.Source
+	prop, err := f.discoveryProps(ctx, feed, target)
+	if err != nil {
+		return nil, err
+	}
+
+	// A server or principal URL leads to the calendar home
+	if prop.ResourceType.Calendar == nil && prop.CalendarHomeSet.Href == "" && prop.CurrentUserPrincipal.Href != "" {
+		if principal := resolveHref(target, prop.CurrentUserPrincipal.Href); principal != target {
+			target = principal
+			if prop, err = f.discoveryProps(ctx, feed, target); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	switch {
+	case prop.ResourceType.Calendar != nil:
+		return []string{target}, nil
+	case prop.CalendarHomeSet.Href != "":
+		return f.listCalendars(ctx, feed, resolveHref(target, prop.CalendarHomeSet.Href))
+	default:
+		// Without a home set the source itself may contain the calendars
+		return f.listCalendars(ctx, feed, target)
+	}
+}
+
+// discoveryProps returns the discovery properties of target itself
+func (f *Fetcher) discoveryProps(ctx context.Context, feed Feed, target string) (davProp, error) {
+	responses, err := f.propfind(ctx, feed, target, "0")
+	if err != nil || len(responses) == 0 {
+		return davProp{}, err
+	}
+	return responses[0].prop(), nil
+}
+
+// listCalendars returns the calendar collections directly inside home
+func (f *Fetcher) listCalendars(ctx context.Context, feed Feed, home string) ([]string, error) {
+	responses, err := f.propfind(ctx, feed, home, "1")
+	if err != nil {
+		return nil, err
+	}
+
+	var calendars []string
+	for _, r := range responses {
+		if r.prop().ResourceType.Calendar == nil {
+			continue
+		}
+		calendars = append(calendars, resolveHref(home, r.Href))
+	}
+	return calendars, nil
+}
+
+// propfind requests the discovery properties of target
+func (f *Fetcher) propfind(ctx context.Context, feed Feed, target, depth string) ([]davResponse, error) {
+	resp, err := f.davRequest(ctx, feed, "PROPFIND", target, depth, propfindBody)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var multistatus struct {
+		Responses []davResponse `xml:"DAV: response"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&multistatus); err != nil {
+		return nil, fmt.Errorf("invalid PROPFIND response: %w", err)
+	}
+	return multistatus.Responses, nil
+}
+
+// queryCalendar runs a calendar-query REPORT for one component type and
+// parses each returned calendar object as it is read, copying it to data
+func (f *Fetcher) queryCalendar(ctx context.Context, feed Feed, calendar, component string, parser *Parser, result *FeedResult, data *bytes.Buffer) error {
+	var timeRange string
+	if !parser.windowStart.IsZero() {
+		timeRange = fmt.Sprintf(`<c:time-range start="%s"`, parser.windowStart.UTC().Format("20060102T150405Z"))
+		if !parser.windowEnd.IsZero() {
+			timeRange += fmt.Sprintf(` end="%s"`, parser.windowEnd.UTC().Format("20060102T150405Z"))
+		}
+		timeRange += "/>"
+	}
+
+	resp, err := f.davRequest(ctx, feed, "REPORT", calendar, "1", fmt.Sprintf(calendarQueryBody, component, timeRange))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Calendar data is always iCalendar text
+	objectFeed := feed
+	objectFeed.Format = FormatICS
+
+	dec := xml.NewDecoder(resp.Body)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid REPORT response: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Space != davNS || start.Name.Local != "response" {
+			continue
+		}
+
+		var r davResponse
+		if err := dec.DecodeElement(&r, &start); err != nil {
+			return fmt.Errorf("invalid REPORT response: %w", err)
+		}
+		object := r.prop().CalendarData
+		if object == "" {
+			continue
+		}
+
+		if err := result.parse(parser, objectFeed, strings.NewReader(object)); err != nil {
+			return err
+		}
+		data.WriteString(strings.TrimRight(object, "\r\n"))
+		data.WriteString("\r\n")
+	}
+}
+
+// davRequest sends a WebDAV request and checks for a multistatus response
+func (f *Fetcher) davRequest(ctx context.Context, feed Feed, method, target, depth, body string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, strings.NewReader(body))
+	if err != nil {
+		return nil, redactError(err)
+	}
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	req.Header.Set("Depth", depth)
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+	feed.Auth.apply(req)
+
+	client, err := f.clientFor(feed)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.do(client, req)
+	if err != nil {
+		return nil, redactError(err)
+	}
+	if resp.StatusCode != http.StatusMultiStatus {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s: unexpected status code: %d", method, RedactURL(target), resp.StatusCode)
+	}
+	if resp.Body, err = f.readBody(resp.Header.Get("Content-Encoding"), resp.Body); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// resolveHref resolves an href from a response against the requested URL
+func resolveHref(base, href string) string {
+	b, err := url.Parse(base)
+	if err != nil {
+		return href
+	}
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return href
+	}
+	return b.ResolveReference(ref).String()
+}
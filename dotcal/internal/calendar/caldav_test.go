@@ -0,0 +1,183 @@
+package calendar
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// caldavServer is a minimal stand-in for a CalDAV server with one user, a
+// work calendar and an inbox that is not a calendar
+type caldavServer struct {
+	*httptest.Server
+	reports []string // bodies of the REPORT requests received
+}
+
+func newCalDAVServer(t *testing.T) *caldavServer {
+	s := &caldavServer{}
+	multistatus := func(w http.ResponseWriter, responses ...string) {
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="utf-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">%s</d:multistatus>`, strings.Join(responses, ""))
+	}
+	response := func(href, props string) string {
+		return fmt.Sprintf(`<d:response><d:href>%s</d:href><d:propstat><d:prop>%s</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat>
+<d:propstat><d:prop><c:calendar-home-set/></d:prop><d:status>HTTP/1.1 404 Not Found</d:status></d:propstat></d:response>`, href, props)
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "me" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+
+		switch {
+		case r.Method == "PROPFIND" && r.URL.Path == "/":
+			multistatus(w, response("/", `<d:resourcetype><d:collection/></d:resourcetype>
+<d:current-user-principal><d:href>/principals/me/</d:href></d:current-user-principal>`))
+		case r.Method == "PROPFIND" && r.URL.Path == "/principals/me/":
+			multistatus(w, response("/principals/me/", `<c:calendar-home-set><d:href>/calendars/me/</d:href></c:calendar-home-set>`))
+		case r.Method == "PROPFIND" && r.URL.Path == "/calendars/me/" && r.Header.Get("Depth") == "1":
+			multistatus(w,
+				response("/calendars/me/", `<d:resourcetype><d:collection/></d:resourcetype>`),
+				response("/calendars/me/work/", `<d:resourcetype><d:collection/><c:calendar/></d:resourcetype>`),
+				response("/calendars/me/inbox/", `<d:resourcetype><d:collection/><c:schedule-inbox/></d:resourcetype>`))
+		case r.Method == "PROPFIND" && r.URL.Path == "/calendars/me/work/":
+			multistatus(w, response("/calendars/me/work/", `<d:resourcetype><d:collection/><c:calendar/></d:resourcetype>`))
+		case r.Method == "REPORT" && r.URL.Path == "/calendars/me/work/":
+			s.reports = append(s.reports, string(body))
+			if !strings.Contains(string(body), `name="VEVENT"`) {
+				multistatus(w)
+				return
+			}
+			multistatus(w,
+				response("/calendars/me/work/standup.ics", `<c:calendar-data>BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:standup@example.com
+SUMMARY:Standup
+DTSTART;TZID=Europe/Berlin:20250310T090000
+DTEND;TZID=Europe/Berlin:20250310T091500
+RRULE:FREQ=DAILY;COUNT=3
+END:VEVENT
+END:VCALENDAR
+</c:calendar-data>`),
+				response("/calendars/me/work/review.ics", `<c:calendar-data>BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:review@example.com
+SUMMARY:Review &amp; planning
+DTSTART:20250313T140000Z
+DTEND:20250313T150000Z
+END:VEVENT
+END:VCALENDAR
+</c:calendar-data>`))
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestFetchCalDAV(t *testing.T) {
+	auth := &Auth{Username: "me", Password: "secret"}
+	parser := NewParser(time.UTC)
+	parser.SetWindow(
+		time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 3, 17, 0, 0, 0, 0, time.UTC),
+	)
+
+	t.Run("discovers calendars from the server URL", func(t *testing.T) {
+		server := newCalDAVServer(t)
+		feed := Feed{ID: "dav", Source: server.URL + "/", IsURL: true, Type: FeedCalDAV, Auth: auth}
+
+		result, err := newTestFetcher().FetchEvents(feed, parser)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		var titles []string
+		for _, event := range result.Events {
+			titles = append(titles, event.Title)
+		}
+		expected := "Standup,Standup,Standup,Review & planning"
+		if strings.Join(titles, ",") != expected {
+			t.Errorf("Expected events %s, got %v", expected, titles)
+		}
+
+		if len(server.reports) != 1 {
+			t.Fatalf("Expected one REPORT, got %d", len(server.reports))
+		}
+		if !strings.Contains(server.reports[0], `<c:time-range start="20250310T000000Z" end="20250317T000000Z"/>`) {
+			t.Errorf("Expected the query to be limited to the window, got %s", server.reports[0])
+		}
+	})
+
+	t.Run("calendar URL", func(t *testing.T) {
+		server := newCalDAVServer(t)
+		feed := Feed{Source: server.URL + "/calendars/me/work/", IsURL: true, Type: FeedCalDAV, Auth: auth}
+
+		result, err := newTestFetcher().FetchEvents(feed, parser)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(result.Events) != 4 {
+			t.Errorf("Expected 4 events, got %d", len(result.Events))
+		}
+	})
+
+	t.Run("to-dos are queried separately", func(t *testing.T) {
+		server := newCalDAVServer(t)
+		todos := NewParser(time.UTC)
+		todos.SetIncludeTodos(true)
+		feed := Feed{Source: server.URL + "/calendars/me/work/", IsURL: true, Type: FeedCalDAV, Auth: auth}
+
+		if _, err := newTestFetcher().FetchEvents(feed, todos); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(server.reports) != 2 || !strings.Contains(server.reports[1], `name="VTODO"`) {
+			t.Errorf("Expected a VTODO query, got %v", server.reports)
+		}
+		if strings.Contains(server.reports[0], "time-range") {
+			t.Errorf("Expected no time-range without a window, got %s", server.reports[0])
+		}
+	})
+
+	t.Run("falls back to the cached calendars", func(t *testing.T) {
+		server := newCalDAVServer(t)
+		feed := Feed{ID: "dav", Source: server.URL + "/", IsURL: true, Type: FeedCalDAV, Auth: auth}
+		fetcher := newTestFetcher()
+		fetcher.SetCacheDir(t.TempDir())
+
+		result, err := fetcher.FetchEvents(feed, parser)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result.Cache != CacheMiss {
+			t.Errorf("Expected the calendars to be cached, got %q", result.Cache)
+		}
+
+		server.Close()
+		stale, err := fetcher.FetchEvents(feed, parser)
+		if err != nil {
+			t.Fatalf("Expected the cached copy, got %v", err)
+		}
+		if !stale.Stale || len(stale.Events) != len(result.Events) {
+			t.Errorf("Expected %d stale events, got %d (stale %v)", len(result.Events), len(stale.Events), stale.Stale)
+		}
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		server := newCalDAVServer(t)
+		feed := Feed{Source: server.URL + "/", IsURL: true, Type: FeedCalDAV}
+
+		if _, err := newTestFetcher().FetchEvents(feed, parser); err == nil {
+			t.Error("Expected error without credentials")
+		}
+	})
+}
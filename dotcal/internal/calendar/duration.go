@@ -0,0 +1,82 @@
+package calendar
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// duration is an RFC 5545 DURATION value. Weeks and days are nominal and
+// follow the wall clock across DST changes; the time part is exact.
+type duration struct {
+	days  int
+	exact time.Duration
+}
+
+// parseDuration parses an ISO 8601 duration such as PT45M, P1DT2H or P2W.
+// Negative durations are rejected since they cannot describe an event.
+func parseDuration(value string) (duration, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	rest := strings.TrimPrefix(value, "+")
+	if strings.HasPrefix(rest, "-") {
+		return duration{}, fmt.Errorf("negative duration %q", value)
+	}
+	if !strings.HasPrefix(rest, "P") {
+		return duration{}, fmt.Errorf("invalid duration %q", value)
+	}
+	rest = rest[1:]
+
+	var d duration
+	inTime := false
+	components := 0
+	for len(rest) > 0 {
+		if rest[0] == 'T' {
+			if inTime {
+				return duration{}, fmt.Errorf("invalid duration %q", value)
+			}
+			inTime = true
+			rest = rest[1:]
+			continue
+		}
+
+		i := 0
+		for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+			i++
+		}
+		if i == 0 || i == len(rest) {
+			return duration{}, fmt.Errorf("invalid duration %q", value)
+		}
+		n, err := strconv.Atoi(rest[:i])
+		if err != nil {
+			return duration{}, fmt.Errorf("invalid duration %q: %w", value, err)
+		}
+
+		switch unit := rest[i]; {
+		case unit == 'W' && !inTime:
+			d.days += 7 * n
+		case unit == 'D' && !inTime:
+			d.days += n
+		case unit == 'H' && inTime:
+			d.exact += time.Duration(n) * time.Hour
+		case unit == 'M' && inTime:
+			d.exact += time.Duration(n) * time.Minute
+		case unit == 'S' && inTime:
+			d.exact += time.Duration(n) * time.Second
+		default:
+			return duration{}, fmt.Errorf("invalid duration %q", value)
+		}
+		components++
+		rest = rest[i+1:]
+	}
+
+	if components == 0 {
+		return duration{}, fmt.Errorf("empty duration %q", value)
+	}
+	return d, nil
+}
+
+// addTo returns t advanced by the duration
+func (d duration) addTo(t time.Time) time.Time {
+	return t.AddDate(0, 0, d.days).Add(d.exact)
+}
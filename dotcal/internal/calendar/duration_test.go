@@ -0,0 +1,61 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value   string
+		days    int
+		exact   time.Duration
+		wantErr bool
+	}{
+		{"PT45M", 0, 45 * time.Minute, false},
+		{"P1DT2H30M", 1, 2*time.Hour + 30*time.Minute, false},
+		{"P2W", 14, 0, false},
+		{"+PT15S", 0, 15 * time.Second, false},
+		{"P1D", 1, 0, false},
+		{"-PT15M", 0, 0, true},
+		{"P", 0, 0, true},
+		{"PT", 0, 0, true},
+		{"P1H", 0, 0, true},
+		{"PT1D", 0, 0, true},
+		{"45M", 0, 0, true},
+	}
+
+	for _, tc := range tests {
+		got, err := parseDuration(tc.value)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("Expected error for %q", tc.value)
+			}
+			continue
+		}
+		if err != nil || got.days != tc.days || got.exact != tc.exact {
+			t.Errorf("parseDuration(%q) = %+v, %v; want %d days %v", tc.value, got, err, tc.days, tc.exact)
+		}
+	}
+}
+
+func TestDurationAddTo(t *testing.T) {
+	nyc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("timezone data unavailable")
+	}
+
+	// Days are nominal: one day across the spring DST change is 23 hours
+	start := time.Date(2025, 3, 8, 9, 0, 0, 0, nyc)
+	got := duration{days: 1}.addTo(start)
+	expected := time.Date(2025, 3, 9, 9, 0, 0, 0, nyc)
+	if !got.Equal(expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	got = duration{exact: 24 * time.Hour}.addTo(start)
+	expected = time.Date(2025, 3, 9, 10, 0, 0, 0, nyc)
+	if !got.Equal(expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,50 @@
+package calendar
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ParseMode controls how malformed content in a feed is handled
+type ParseMode string
+
+const (
+	// ParseLenient skips malformed events and reports them
+	ParseLenient ParseMode = "lenient"
+	// ParseStrict fails the whole feed when any content is malformed
+	ParseStrict ParseMode = "strict"
+)
+
+// errMissingStart is reported for events without a DTSTART
+var errMissingStart = errors.New("missing DTSTART")
+
+// ParseError describes content of a feed that could not be parsed
+type ParseError struct {
+	FeedID   string
+	Line     int    // Physical line the property starts on
+	Property string // Property name, e.g. DTSTART
+	Raw      string // Unfolded content line
+	Err      error
+	// Warning marks well-formed content that is only partly supported,
+	// such as an RRULE with BYHOUR; strict mode does not fail on it
+	Warning bool
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("feed %s line %d: %s: %v", e.FeedID, e.Line, e.Property, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// malformed returns the parse errors that are not warnings
+func malformed(parseErrors []*ParseError) []*ParseError {
+	var result []*ParseError
+	for _, perr := range parseErrors {
+		if !perr.Warning {
+			result = append(result, perr)
+		}
+	}
+	return result
+}
@@ -0,0 +1,89 @@
+package calendar
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseErrors(t *testing.T) {
+	input := `BEGIN:VCALENDAR
+BEGIN:VEVENT
+SUMMARY:Good
+DTSTART:20250310T090000Z
+DTEND:20250310T100000Z
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:Bad start
+DTSTART:2025-03-11 09:00
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:No start
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:Broken line
+DTSTART:20250312T090000Z
+THIS LINE HAS NO COLON
+END:VEVENT
+END:VCALENDAR`
+
+	feed := Feed{ID: "team"}
+
+	t.Run("lenient mode skips bad events", func(t *testing.T) {
+		events, parseErrors, err := NewParser(time.UTC).ParseFeed(feed, []byte(input))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(events) != 1 || events[0].Title != "Good" {
+			t.Errorf("Expected only the good event, got %v", events)
+		}
+
+		expected := []struct {
+			line     int
+			property string
+		}{
+			{9, "DTSTART"},
+			{11, "VEVENT"},
+			{17, "THIS LINE HAS NO COLON"},
+		}
+		if len(parseErrors) != len(expected) {
+			t.Fatalf("Expected %d parse errors, got %d: %v", len(expected), len(parseErrors), parseErrors)
+		}
+		for i, e := range expected {
+			perr := parseErrors[i]
+			if perr.FeedID != "team" || perr.Line != e.line || perr.Property != e.property {
+				t.Errorf("Error %d: expected team line %d %s, got %s line %d %s",
+					i, e.line, e.property, perr.FeedID, perr.Line, perr.Property)
+			}
+		}
+		if parseErrors[0].Raw != "DTSTART:2025-03-11 09:00" {
+			t.Errorf("Expected raw content line, got %q", parseErrors[0].Raw)
+		}
+		if !errors.Is(parseErrors[1], errMissingStart) {
+			t.Errorf("Expected missing DTSTART error, got %v", parseErrors[1])
+		}
+		if !strings.Contains(parseErrors[0].Error(), "feed team line 9: DTSTART") {
+			t.Errorf("Expected error message to locate the problem, got %q", parseErrors[0].Error())
+		}
+	})
+
+	t.Run("strict mode fails the feed", func(t *testing.T) {
+		parser := NewParser(time.UTC)
+		parser.SetMode(ParseStrict)
+		events, parseErrors, err := parser.ParseFeed(feed, []byte(input))
+		if err == nil {
+			t.Fatal("Expected strict mode to fail the feed")
+		}
+		if events != nil {
+			t.Errorf("Expected no events, got %v", events)
+		}
+		if len(parseErrors) != 3 {
+			t.Errorf("Expected parse errors to be reported, got %v", parseErrors)
+		}
+		var perr *ParseError
+		if !errors.As(err, &perr) || perr.Line != 9 {
+			t.Errorf("Expected error to wrap the first parse error, got %v", err)
+		}
+	})
+}
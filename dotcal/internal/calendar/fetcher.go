@@ -1,55 +1,434 @@
 package calendar
 
 import (
+	"context"
+	"crypto/x509"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"sync"
 	"time"
+
+	"github.com/zach/dotcal/internal/logger"
 )
 
+// defaultFetchTimeout bounds fetching a feed, including retries and reading
+// the body, unless the feed sets its own timeout
+const defaultFetchTimeout = 30 * time.Second
+
 // Fetcher handles retrieving calendar data from various sources
 type Fetcher struct {
-	client *http.Client
+	client       *http.Client
+	proxy        func(*http.Request) (*url.URL, error)
+	rootCAs      *x509.CertPool // nil for the system's CAs
+	timeout      time.Duration
+	retry        RetryPolicy
+	cache        *feedCache    // nil when caching is disabled
+	maxStaleness time.Duration // Oldest cached copy used as a fallback, 0 for no limit
+	maxFeedSize  int64         // Bytes read per feed after decompression, 0 for no limit
+	guard        *ShrinkGuard  // nil when event counts are not checked
+
+	// Limits of FetchAll
+	concurrency     int
+	hostConcurrency int
+
+	sources map[string]Source // By scheme, for sources other than URLs and files
+
+	mu      sync.Mutex
+	clients map[string]*http.Client // By feed ID, for feeds with TLS settings
 }
 
 // NewFetcher creates a new calendar fetcher
 func NewFetcher() *Fetcher {
-	return &Fetcher{
-		client: &http.Client{
-			Timeout: 30 * time.Second,
+	f := &Fetcher{
+		proxy:           http.ProxyFromEnvironment,
+		timeout:         defaultFetchTimeout,
+		retry:           DefaultRetryPolicy(),
+		maxFeedSize:     defaultMaxFeedSize,
+		concurrency:     defaultConcurrency,
+		hostConcurrency: defaultHostConcurrency,
+		sources: map[string]Source{
+			"exec":  commandSource{},
+			"stdin": stdinSource{r: os.Stdin},
 		},
 	}
+	f.resetClients()
+	return f
+}
+
+// SetTimeout sets how long fetching a feed may take, including retries, for
+// feeds without a timeout of their own. Zero means no limit.
+func (f *Fetcher) SetTimeout(timeout time.Duration) {
+	f.timeout = timeout
+}
+
+// SetRetryPolicy sets how failed requests are retried
+func (f *Fetcher) SetRetryPolicy(policy RetryPolicy) {
+	f.retry = policy
+}
+
+// SetCacheDir enables caching of URL feeds in dir. Cached feeds are
+// requested conditionally and reused when the server reports them
+// unchanged. An empty dir disables caching.
+func (f *Fetcher) SetCacheDir(dir string) {
+	if dir == "" {
+		f.cache = nil
+		return
+	}
+	f.cache = &feedCache{dir: dir}
+}
+
+// SetMaxStaleness limits how old the cached copy of a feed may be to be used
+// when the feed cannot be refreshed. Zero means no limit.
+func (f *Fetcher) SetMaxStaleness(d time.Duration) {
+	f.maxStaleness = d
+}
+
+// SetShrinkGuard checks fetched feeds against their event counts of earlier
+// runs. A feed that shrank too far is treated like a failed fetch, so its
+// cached copy is used when there is one. A nil guard disables the check.
+func (f *Fetcher) SetShrinkGuard(guard *ShrinkGuard) {
+	f.guard = guard
+}
+
+// feedResponse is an opened feed
+type feedResponse struct {
+	body     io.ReadCloser
+	format   Format // indicated by the content type or file extension
+	cache    CacheStatus
+	redirect string // Redacted URL the request was redirected to, if any
+}
+
+// abandon keeps a response that turned out to be unusable from replacing
+// the cached copy
+func (r *feedResponse) abandon() {
+	if body, ok := r.body.(*cachingBody); ok {
+		body.discard()
+	}
 }
 
 // Fetch retrieves calendar data from a feed source
 func (f *Fetcher) Fetch(feed Feed) ([]byte, error) {
+	return f.FetchContext(context.Background(), feed)
+}
+
+// FetchContext retrieves calendar data from a feed source, giving up when ctx
+// is done or the feed's timeout expires
+func (f *Fetcher) FetchContext(ctx context.Context, feed Feed) ([]byte, error) {
+	ctx, cancel := f.withTimeout(ctx, feed)
+	defer cancel()
+
+	resp, err := f.open(ctx, feed)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.body.Close()
+
+	r, err := resp.payload()
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed: %w", err)
+	}
+	return data, nil
+}
+
+// FetchEvents retrieves a feed and parses it as it is downloaded, without
+// holding the whole body in memory. When the feed cannot be fetched or
+// parsed, the last good copy from the cache is used instead and the result
+// is marked stale.
+func (f *Fetcher) FetchEvents(feed Feed, parser *Parser) (*FeedResult, error) {
+	return f.FetchEventsContext(context.Background(), feed, parser)
+}
+
+// FetchEventsContext is FetchEvents giving up on the download when ctx is
+// done or the feed's timeout expires
+func (f *Fetcher) FetchEventsContext(ctx context.Context, feed Feed, parser *Parser) (*FeedResult, error) {
+	fetchCtx, cancel := f.withTimeout(ctx, feed)
+	result, err := f.fetchEvents(fetchCtx, feed, parser)
+	cancel()
+	if err == nil {
+		return result, nil
+	}
+
+	stale, fallbackErr := f.fallback(feed, parser, err)
+	if fallbackErr != nil {
+		logger.Debug("No fallback for feed %s: %v", feed.ID, fallbackErr)
+		return nil, err
+	}
+	return stale, nil
+}
+
+// withTimeout bounds ctx by the feed's timeout, or the fetcher's when the
+// feed has none
+func (f *Fetcher) withTimeout(ctx context.Context, feed Feed) (context.Context, context.CancelFunc) {
+	timeout := f.timeout
+	if feed.Timeout > 0 {
+		timeout = feed.Timeout
+	}
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// fetchEvents retrieves and parses the current version of a feed
+func (f *Fetcher) fetchEvents(ctx context.Context, feed Feed, parser *Parser) (*FeedResult, error) {
+	if feed.Type == FeedCalDAV {
+		return f.fetchCalDAV(ctx, feed, parser)
+	}
+	if !feed.IsURL && f.sources[sourceScheme(feed.Source)] == nil {
+		return f.checkCount(f.fetchFiles(feed, parser))
+	}
+
+	resp, err := f.open(ctx, feed)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.body.Close()
+
+	if feed.Format == "" {
+		feed.Format = resp.format
+	}
+
+	r, err := resp.payload()
+	if err != nil {
+		resp.abandon()
+		return nil, err
+	}
+	result := &FeedResult{Feed: feed, Cache: resp.cache, FetchedAt: time.Now()}
+	if err := result.parse(parser, feed, r); err != nil {
+		resp.abandon()
+		return nil, err
+	}
+	// A collapsed feed must not replace the cached copy it falls back to
+	if _, err := f.checkCount(result, nil); err != nil {
+		resp.abandon()
+		return nil, err
+	}
+	return result, nil
+}
+
+// checkCount passes on a fetched result unless the shrink guard rejects it
+func (f *Fetcher) checkCount(result *FeedResult, err error) (*FeedResult, error) {
+	if err != nil {
+		return nil, err
+	}
+	if err := f.guard.check(result.Feed, len(result.Events)); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// fallback parses the cached copy of a URL or CalDAV feed that could not be
+// refreshed
+func (f *Fetcher) fallback(feed Feed, parser *Parser, fetchErr error) (*FeedResult, error) {
+	if f.cache == nil || !feed.IsURL {
+		return nil, fmt.Errorf("caching is disabled")
+	}
+	entry := f.cache.load(feed.Source)
+	if entry == nil {
+		return nil, fmt.Errorf("no cached copy")
+	}
+	if age := time.Since(entry.FetchedAt); f.maxStaleness > 0 && age > f.maxStaleness {
+		return nil, fmt.Errorf("cached copy is %s old", age.Round(time.Minute))
+	}
+
+	body, err := f.cache.open(feed.Source)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	if feed.Format == "" {
+		feed.Format = formatFromContentType(entry.ContentType)
+	}
+
+	result := &FeedResult{Feed: feed, Stale: true, FetchedAt: entry.FetchedAt, FetchErr: fetchErr}
+	if err := result.parse(parser, feed, body); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// parse adds the events and parse errors of a feed's data to the result
+func (fr *FeedResult) parse(parser *Parser, feed Feed, r io.Reader) error {
+	parseErrors, err := parser.ParseReader(feed, r, func(event Event) error {
+		fr.Events = append(fr.Events, event)
+		return nil
+	})
+	fr.ParseErrors = append(fr.ParseErrors, parseErrors...)
+	return err
+}
+
+// open returns the feed's data, which the caller must close
+func (f *Fetcher) open(ctx context.Context, feed Feed) (*feedResponse, error) {
+	if feed.Type == FeedCalDAV {
+		return nil, fmt.Errorf("CalDAV feeds can only be fetched as events")
+	}
 	if feed.IsURL {
-		return f.fetchURL(feed.Source)
+		return f.openURL(ctx, feed)
+	}
+	if source, ok := f.sources[sourceScheme(feed.Source)]; ok {
+		body, err := source.Open(ctx, feed)
+		if err != nil {
+			return nil, err
+		}
+		if body, err = f.readBody("", body); err != nil {
+			return nil, err
+		}
+		return &feedResponse{body: body}, nil
+	}
+
+	paths, err := expandPath(feed.Source)
+	if err != nil {
+		return nil, err
 	}
-	return f.fetchFile(feed.Source)
+	if len(paths) > 1 {
+		return nil, fmt.Errorf("%s names %d files, which can only be fetched as events", feed.Source, len(paths))
+	}
+	return f.openFile(paths[0])
 }
 
-// fetchURL retrieves calendar data from a URL
-func (f *Fetcher) fetchURL(url string) ([]byte, error) {
-	resp, err := f.client.Get(url)
+// openURL requests calendar data from a URL feed, revalidating the cached
+// copy when there is one
+func (f *Fetcher) openURL(ctx context.Context, feed Feed) (*feedResponse, error) {
+	url := feed.Source
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, httpURL(url), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch URL: %w", redactError(err))
+	}
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+	feed.Auth.apply(req)
+
+	var cached *cacheEntry
+	if f.cache != nil {
+		cached = f.cache.load(url)
+	}
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	client, err := f.clientFor(feed)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch URL: %w", err)
+		return nil, err
+	}
+	resp, err := f.do(client, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch URL: %w", redactError(err))
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		resp.Body.Close()
+		body, err := f.cache.open(url)
+		if err != nil {
+			return nil, err
+		}
+		// The copy is confirmed current, which restarts its staleness
+		cached.FetchedAt = time.Now()
+		if err := f.cache.writeEntry(*cached); err != nil {
+			logger.Error("Failed to update feed cache: %v", err)
+		}
+		return &feedResponse{body: body, format: formatFromContentType(cached.ContentType), cache: CacheHit}, nil
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
+	var redirect string
+	if final := resp.Request.URL; final.String() != req.URL.String() {
+		redirect = RedactURL(final.String())
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		resp.Body.Close()
+		return nil, fmt.Errorf("feed requires authentication or rejected its credentials: status code %d", resp.StatusCode)
+	default:
+		resp.Body.Close()
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
-	return io.ReadAll(resp.Body)
+	// The cache keeps the decoded body, so it is decoded before it is stored
+	body, err := f.readBody(resp.Header.Get("Content-Encoding"), resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if f.cache == nil {
+		return &feedResponse{body: body, format: formatFromContentType(contentType), redirect: redirect}, nil
+	}
+
+	entry := cacheEntry{
+		Source:       url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		ContentType:  contentType,
+		FetchedAt:    time.Now(),
+	}
+	return &feedResponse{
+		body:     f.cache.store(entry, body),
+		format:   formatFromContentType(contentType),
+		cache:    CacheMiss,
+		redirect: redirect,
+	}, nil
 }
 
-// fetchFile retrieves calendar data from a local file
-func (f *Fetcher) fetchFile(path string) ([]byte, error) {
-	data, err := os.ReadFile(path)
+// do sends a request, retrying it according to the fetcher's retry policy
+// while the request's context allows
+func (f *Fetcher) do(client *http.Client, req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for retry := 1; ; retry++ {
+		resp, err := client.Do(req)
+		again, wait := f.retry.retryWait(resp, err, retry)
+		if !again {
+			return resp, err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return resp, err
+		}
+
+		if err != nil {
+			logger.Debug("Request failed, retrying in %s: %v", wait, redactError(err))
+		} else {
+			logger.Debug("Request failed with status %d, retrying in %s", resp.StatusCode, wait)
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+
+		// A request body has been consumed by the failed attempt
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+	}
+}
+
+// openFile opens calendar data from a local file
+func (f *Fetcher) openFile(path string) (*feedResponse, error) {
+	file, err := os.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
-	return data, nil
+	body, err := f.readBody("", file)
+	if err != nil {
+		return nil, err
+	}
+	return &feedResponse{body: body, format: formatFromPath(path)}, nil
 }
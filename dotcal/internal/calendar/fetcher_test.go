@@ -1,11 +1,13 @@
 package calendar
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestNewFetcher(t *testing.T) {
@@ -19,7 +21,7 @@ func TestNewFetcher(t *testing.T) {
 }
 
 func TestFetch(t *testing.T) {
-	fetcher := NewFetcher()
+	fetcher := newTestFetcher()
 
 	t.Run("fetch from URL", func(t *testing.T) {
 		// Create test server
@@ -137,3 +139,226 @@ func TestFetch(t *testing.T) {
 		}
 	})
 }
+
+func TestFetchEvents(t *testing.T) {
+	fetcher := newTestFetcher()
+	parser := NewParser(time.UTC)
+	parser.SetWindow(
+		time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 3, 17, 0, 0, 0, 0, time.UTC),
+	)
+
+	testData := `BEGIN:VCALENDAR
+BEGIN:VEVENT
+SUMMARY:In window
+DTSTART:20250311T090000Z
+DTEND:20250311T100000Z
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:Years ago
+DTSTART:20190311T090000Z
+DTEND:20190311T100000Z
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:Broken
+DTSTART:yesterday
+END:VEVENT
+END:VCALENDAR`
+
+	t.Run("parses response body", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(testData))
+		}))
+		defer server.Close()
+
+		feed := Feed{ID: "team", Source: server.URL, IsURL: true}
+		result, err := fetcher.FetchEvents(feed, parser)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result.Feed.ID != "team" {
+			t.Errorf("Expected result for feed team, got %q", result.Feed.ID)
+		}
+		if len(result.Events) != 1 || result.Events[0].Title != "In window" {
+			t.Errorf("Expected only the event in the window, got %v", result.Events)
+		}
+		if len(result.ParseErrors) != 1 || result.ParseErrors[0].Line != 14 {
+			t.Errorf("Expected the broken event to be reported, got %v", result.ParseErrors)
+		}
+	})
+
+	t.Run("HTTP error response", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		if _, err := fetcher.FetchEvents(Feed{Source: server.URL, IsURL: true}, parser); err == nil {
+			t.Error("Expected error for HTTP 404 response")
+		}
+	})
+}
+
+func TestFetchEventsFormats(t *testing.T) {
+	fetcher := newTestFetcher()
+	parser := NewParser(time.UTC)
+
+	jcal := `["vcalendar", [], [["vevent", [
+		["summary", {}, "text", "From jCal"],
+		["dtstart", {}, "date-time", "2025-03-11T09:00:00Z"],
+		["dtend", {}, "date-time", "2025-03-11T10:00:00Z"]
+	], []]]]`
+
+	t.Run("content type", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/calendar+json")
+			w.Write([]byte(jcal))
+		}))
+		defer server.Close()
+
+		result, err := fetcher.FetchEvents(Feed{Source: server.URL, IsURL: true}, parser)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result.Feed.Format != FormatJCal {
+			t.Errorf("Expected format %q, got %q", FormatJCal, result.Feed.Format)
+		}
+		if len(result.Events) != 1 || result.Events[0].Title != "From jCal" {
+			t.Errorf("Expected the jCal event, got %v", result.Events)
+		}
+	})
+
+	t.Run("sniffed from file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "calendar")
+		if err := os.WriteFile(path, []byte(jcal), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+
+		result, err := fetcher.FetchEvents(Feed{Source: path}, parser)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(result.Events) != 1 || result.Events[0].Title != "From jCal" {
+			t.Errorf("Expected the jCal event, got %v", result.Events)
+		}
+	})
+}
+
+// newTestFetcher returns a fetcher that retries without noticeable waits
+func newTestFetcher() *Fetcher {
+	fetcher := NewFetcher()
+	fetcher.SetRetryPolicy(RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     10 * time.Millisecond,
+	})
+	return fetcher
+}
+
+func TestFetchRetries(t *testing.T) {
+	testData := "BEGIN:VCALENDAR\nEND:VCALENDAR"
+
+	t.Run("retries server errors", func(t *testing.T) {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if requests < 3 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Write([]byte(testData))
+		}))
+		defer server.Close()
+
+		data, err := newTestFetcher().Fetch(Feed{Source: server.URL, IsURL: true})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if string(data) != testData || requests != 3 {
+			t.Errorf("Expected data after 3 requests, got %q after %d", data, requests)
+		}
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		if _, err := newTestFetcher().Fetch(Feed{Source: server.URL, IsURL: true}); err == nil {
+			t.Error("Expected error after retries")
+		}
+		if requests != 3 {
+			t.Errorf("Expected 3 requests, got %d", requests)
+		}
+	})
+
+	t.Run("client errors are not retried", func(t *testing.T) {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer server.Close()
+
+		newTestFetcher().Fetch(Feed{Source: server.URL, IsURL: true})
+		if requests != 1 {
+			t.Errorf("Expected 1 request, got %d", requests)
+		}
+	})
+
+	t.Run("Retry-After beyond max backoff gives up", func(t *testing.T) {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer server.Close()
+
+		if _, err := newTestFetcher().Fetch(Feed{Source: server.URL, IsURL: true}); err == nil {
+			t.Error("Expected error for rate limited feed")
+		}
+		if requests != 1 {
+			t.Errorf("Expected 1 request, got %d", requests)
+		}
+	})
+}
+
+func TestFetchTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	t.Run("per-feed timeout", func(t *testing.T) {
+		fetcher := newTestFetcher()
+		start := time.Now()
+		_, err := fetcher.Fetch(Feed{Source: server.URL, IsURL: true, Timeout: 50 * time.Millisecond})
+		if err == nil {
+			t.Error("Expected timeout error")
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Expected the feed timeout to apply, took %v", elapsed)
+		}
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		if _, err := newTestFetcher().FetchEventsContext(ctx, Feed{Source: server.URL, IsURL: true}, NewParser(time.UTC)); err == nil {
+			t.Error("Expected error for cancelled context")
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Expected the context to stop the fetch, took %v", elapsed)
+		}
+	})
+}
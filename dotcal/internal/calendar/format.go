@@ -0,0 +1,160 @@
+package calendar
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Format is the representation a feed is published in
+type Format string
+
+const (
+	// FormatICS is the iCalendar text format (RFC 5545)
+	FormatICS Format = "ics"
+	// FormatJCal is the JSON representation of iCalendar (RFC 7265)
+	FormatJCal Format = "jcal"
+	// FormatXCal is the XML representation of iCalendar (RFC 6321)
+	FormatXCal Format = "xcal"
+)
+
+// formatFromContentType maps a Content-Type header onto a format, returning
+// "" when the type does not identify one
+func formatFromContentType(contentType string) Format {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	switch mediaType {
+	case "text/calendar":
+		return FormatICS
+	case "application/calendar+json":
+		return FormatJCal
+	case "application/calendar+xml":
+		return FormatXCal
+	default:
+		return ""
+	}
+}
+
+// formatFromPath guesses a local file's format from its extension, looking
+// past a .gz extension
+func formatFromPath(path string) Format {
+	path = strings.TrimSuffix(strings.ToLower(path), ".gz")
+	switch filepath.Ext(path) {
+	case ".ics", ".ical", ".ifb":
+		return FormatICS
+	case ".jcal", ".json":
+		return FormatJCal
+	case ".xcal", ".xcs", ".xml":
+		return FormatXCal
+	default:
+		return ""
+	}
+}
+
+// sniffFormat detects the format from the start of the data without
+// consuming it: jCal is a JSON array and xCal an XML document
+func sniffFormat(r *bufio.Reader) Format {
+	head, _ := r.Peek(512)
+	head = bytes.TrimPrefix(head, utf8BOM)
+	head = bytes.TrimLeft(head, " \t\r\n")
+	switch {
+	case bytes.HasPrefix(head, []byte("[")):
+		return FormatJCal
+	case bytes.HasPrefix(head, []byte("<")):
+		return FormatXCal
+	default:
+		return FormatICS
+	}
+}
+
+// toICS returns a reader of iCalendar content lines for data in the given
+// format, detecting the format when it is not known
+func toICS(format Format, r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	if format == "" {
+		format = sniffFormat(br)
+	}
+
+	if format == FormatICS {
+		return br, nil
+	}
+
+	// Unlike the lexer, the JSON and XML decoders do not skip a BOM
+	if head, _ := br.Peek(len(utf8BOM)); bytes.Equal(head, utf8BOM) {
+		br.Discard(len(utf8BOM))
+	}
+
+	switch format {
+	case FormatJCal:
+		data, err := jcalToICS(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert jCal: %w", err)
+		}
+		return bytes.NewReader(data), nil
+	case FormatXCal:
+		data, err := xcalToICS(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert xCal: %w", err)
+		}
+		return bytes.NewReader(data), nil
+	default:
+		return nil, fmt.Errorf("unknown feed format %q", format)
+	}
+}
+
+// icalWriter writes iCalendar content lines for the jCal and xCal converters
+type icalWriter struct {
+	buf bytes.Buffer
+}
+
+// line writes a single content line. Parameters are written in name order
+// and quoted when their value requires it.
+func (w *icalWriter) line(name string, params map[string]string, value string) {
+	w.buf.WriteString(strings.ToUpper(name))
+
+	names := make([]string, 0, len(params))
+	for param := range params {
+		names = append(names, param)
+	}
+	sort.Strings(names)
+	for _, param := range names {
+		value := params[param]
+		if strings.ContainsAny(value, ":;,") {
+			value = `"` + strings.ReplaceAll(value, `"`, "'") + `"`
+		}
+		fmt.Fprintf(&w.buf, ";%s=%s", strings.ToUpper(param), value)
+	}
+
+	w.buf.WriteByte(':')
+	// Folding is not needed, but line breaks must not end the line early
+	w.buf.WriteString(strings.NewReplacer("\r\n", `\n`, "\n", `\n`).Replace(value))
+	w.buf.WriteString("\r\n")
+}
+
+// icalValue converts a jCal or xCal value of the given type into its
+// iCalendar text form
+func icalValue(valueType, value string) string {
+	switch valueType {
+	case "text":
+		return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`).Replace(value)
+	case "date", "date-time", "time":
+		return strings.NewReplacer("-", "", ":", "").Replace(value)
+	case "utc-offset":
+		return strings.ReplaceAll(value, ":", "")
+	case "period":
+		start, end, _ := strings.Cut(value, "/")
+		if strings.HasPrefix(strings.TrimPrefix(end, "+"), "P") {
+			return icalValue("date-time", start) + "/" + end
+		}
+		return icalValue("date-time", start) + "/" + icalValue("date-time", end)
+	default:
+		return value
+	}
+}
@@ -0,0 +1,97 @@
+package calendar
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestFormatDetection(t *testing.T) {
+	t.Run("content type", func(t *testing.T) {
+		tests := map[string]Format{
+			"text/calendar; charset=utf-8": FormatICS,
+			"application/calendar+json":    FormatJCal,
+			"application/calendar+xml":     FormatXCal,
+			"text/plain":                   "",
+			"":                             "",
+		}
+		for contentType, expected := range tests {
+			if got := formatFromContentType(contentType); got != expected {
+				t.Errorf("Expected %q for %q, got %q", expected, contentType, got)
+			}
+		}
+	})
+
+	t.Run("path", func(t *testing.T) {
+		tests := map[string]Format{
+			"/data/work.ics":    FormatICS,
+			"/data/work.JSON":   FormatJCal,
+			"/data/work.xml":    FormatXCal,
+			"/data/work.ics.gz": FormatICS,
+			"/data/work":        "",
+		}
+		for path, expected := range tests {
+			if got := formatFromPath(path); got != expected {
+				t.Errorf("Expected %q for %q, got %q", expected, path, got)
+			}
+		}
+	})
+
+	t.Run("sniffing", func(t *testing.T) {
+		tests := map[string]Format{
+			"BEGIN:VCALENDAR\r\n":                     FormatICS,
+			"\xEF\xBB\xBF  \n[\"vcalendar\", [], []]": FormatJCal,
+			"<?xml version=\"1.0\"?><icalendar/>":     FormatXCal,
+			"":                                        FormatICS,
+		}
+		for data, expected := range tests {
+			r := bufio.NewReader(strings.NewReader(data))
+			if got := sniffFormat(r); got != expected {
+				t.Errorf("Expected %q for %q, got %q", expected, data, got)
+			}
+			// Sniffing must not consume the data
+			rest, _ := io.ReadAll(r)
+			if string(rest) != data {
+				t.Errorf("Expected data to be preserved, got %q", rest)
+			}
+		}
+	})
+}
+
+func TestToICS(t *testing.T) {
+	t.Run("ics passes through", func(t *testing.T) {
+		r, err := toICS(FormatICS, strings.NewReader("BEGIN:VCALENDAR"))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		data, _ := io.ReadAll(r)
+		if string(data) != "BEGIN:VCALENDAR" {
+			t.Errorf("Expected unchanged data, got %q", data)
+		}
+	})
+
+	t.Run("invalid jcal", func(t *testing.T) {
+		if _, err := toICS(FormatJCal, strings.NewReader("[\"vcalendar\"")); err == nil {
+			t.Error("Expected error for truncated jCal")
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		if _, err := toICS("csv", strings.NewReader("")); err == nil {
+			t.Error("Expected error for unknown format")
+		}
+	})
+}
+
+func TestIcalWriter(t *testing.T) {
+	w := &icalWriter{}
+	w.line("dtstart", map[string]string{"tzid": "Europe/Berlin", "x-note": "a;b"}, "20250310T090000")
+	w.line("description", nil, icalValue("text", "Line one\nsemi; colon, comma"))
+
+	expected := "DTSTART;TZID=Europe/Berlin;X-NOTE=\"a;b\":20250310T090000\r\n" +
+		`DESCRIPTION:Line one\nsemi\; colon\, comma` + "\r\n"
+	if got := w.buf.String(); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
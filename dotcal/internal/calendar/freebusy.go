@@ -0,0 +1,44 @@
+package calendar
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseFreeBusy turns a FREEBUSY property of a VFREEBUSY component into one
+// event per busy period. Periods are either "start/end" or "start/duration".
+func (st *parseState) parseFreeBusy(prop property) ([]*vevent, error) {
+	status := parseFBType(prop.params["FBTYPE"])
+	periods, err := st.parsePeriodList(prop)
+	if err != nil {
+		return nil, err
+	}
+
+	var blocks []*vevent
+	for _, pd := range periods {
+		if !pd.end.After(pd.start) {
+			return nil, fmt.Errorf("FREEBUSY value %s is not a period", pd.start.Format("20060102T150405Z0700"))
+		}
+		blocks = append(blocks, &vevent{Event: Event{
+			Start:  pd.start,
+			End:    pd.end,
+			Status: status,
+		}})
+	}
+	return blocks, nil
+}
+
+// parseFBType maps an FBTYPE parameter onto availability. The RFC default is
+// BUSY, and unknown types must be treated as BUSY as well.
+func parseFBType(fbtype string) Status {
+	switch strings.ToUpper(strings.TrimSpace(fbtype)) {
+	case "FREE":
+		return StatusAvailable
+	case "BUSY-TENTATIVE":
+		return StatusTentative
+	case "BUSY-UNAVAILABLE":
+		return StatusOutOfOffice
+	default:
+		return StatusBusy
+	}
+}
@@ -0,0 +1,58 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseFreeBusy(t *testing.T) {
+	input := `BEGIN:VCALENDAR
+BEGIN:VFREEBUSY
+UID:fb@example.com
+DTSTART:20250310T000000Z
+DTEND:20250317T000000Z
+FREEBUSY:20250310T090000Z/20250310T100000Z,20250311T140000Z/PT30M
+FREEBUSY;FBTYPE=BUSY-TENTATIVE:20250312T100000Z/20250312T110000Z
+FREEBUSY;FBTYPE=BUSY-UNAVAILABLE:20250313T090000Z/PT8H
+FREEBUSY;FBTYPE=FREE:20250314T090000Z/20250314T100000Z
+FREEBUSY;FBTYPE=X-SOMETHING:20250314T120000Z/PT1H
+END:VFREEBUSY
+END:VCALENDAR`
+
+	events, err := NewParser(time.UTC).Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []struct {
+		start  time.Time
+		end    time.Time
+		status Status
+	}{
+		{time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC), time.Date(2025, 3, 10, 10, 0, 0, 0, time.UTC), StatusBusy},
+		{time.Date(2025, 3, 11, 14, 0, 0, 0, time.UTC), time.Date(2025, 3, 11, 14, 30, 0, 0, time.UTC), StatusBusy},
+		{time.Date(2025, 3, 12, 10, 0, 0, 0, time.UTC), time.Date(2025, 3, 12, 11, 0, 0, 0, time.UTC), StatusTentative},
+		{time.Date(2025, 3, 13, 9, 0, 0, 0, time.UTC), time.Date(2025, 3, 13, 17, 0, 0, 0, time.UTC), StatusOutOfOffice},
+		{time.Date(2025, 3, 14, 9, 0, 0, 0, time.UTC), time.Date(2025, 3, 14, 10, 0, 0, 0, time.UTC), StatusAvailable},
+		{time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC), time.Date(2025, 3, 14, 13, 0, 0, 0, time.UTC), StatusBusy},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, got %d: %v", len(expected), len(events), events)
+	}
+	for i, e := range expected {
+		got := events[i]
+		if !got.Start.Equal(e.start) || !got.End.Equal(e.end) || got.Status != e.status {
+			t.Errorf("Event %d: expected %v-%v %s, got %v-%v %s", i, e.start, e.end, e.status, got.Start, got.End, got.Status)
+		}
+	}
+
+	t.Run("periods merge into the schedule", func(t *testing.T) {
+		schedule := NewMerger(time.UTC).MergeEvents(events, 2025, 11)
+		if slot := schedule.Days[time.Monday][0]; slot.Status != StatusBusy {
+			t.Errorf("Expected Monday 9 AM to be busy, got %v", slot.Status)
+		}
+		if slot := schedule.Days[time.Wednesday][2]; slot.Status != StatusTentative {
+			t.Errorf("Expected Wednesday 10 AM to be tentative, got %v", slot.Status)
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package calendar
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/zach/dotcal/internal/logger"
+)
+
+const (
+	defaultShrinkThreshold = 0.5
+	defaultShrinkGrace     = 24 * time.Hour
+	// defaultShrinkMinEvents guards every feed that had any events, so even
+	// a small calendar collapsing to none is caught
+	defaultShrinkMinEvents = 1
+)
+
+// ShrinkError reports a feed whose event count dropped too far since the
+// last run, such as a feed whose share link expired and now returns an
+// empty calendar
+type ShrinkError struct {
+	FeedID   string
+	Previous int
+	Current  int
+}
+
+func (e *ShrinkError) Error() string {
+	return fmt.Sprintf("feed %s shrank from %d to %d events", e.FeedID, e.Previous, e.Current)
+}
+
+// feedCount is the remembered event count of a feed
+type feedCount struct {
+	Events    int       `json:"events"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// A smaller count seen since, which is accepted once it persists for
+	// the grace period
+	Dropped      int       `json:"dropped,omitempty"`
+	DroppedSince time.Time `json:"droppedSince,omitempty"`
+}
+
+// ShrinkGuard remembers the event count of each feed between runs and
+// rejects results that shrank beyond a threshold. Counts are stored by the
+// SHA-256 of the feed's source, like the feed cache.
+type ShrinkGuard struct {
+	path      string
+	threshold float64
+	grace     time.Duration
+	minEvents int
+
+	mu     sync.Mutex
+	counts map[string]feedCount
+}
+
+// NewShrinkGuard creates a guard keeping its counts in path
+func NewShrinkGuard(path string) *ShrinkGuard {
+	return &ShrinkGuard{
+		path:      path,
+		threshold: defaultShrinkThreshold,
+		grace:     defaultShrinkGrace,
+		minEvents: defaultShrinkMinEvents,
+		counts:    make(map[string]feedCount),
+	}
+}
+
+// SetThreshold sets the fraction of its events a feed may lose between runs,
+// e.g. 0.5 to reject feeds that lost more than half of them
+func (g *ShrinkGuard) SetThreshold(fraction float64) {
+	g.threshold = fraction
+}
+
+// SetGracePeriod sets how long a smaller count must persist before it is
+// accepted as the feed's new size. Zero means it is never accepted.
+func (g *ShrinkGuard) SetGracePeriod(d time.Duration) {
+	g.grace = d
+}
+
+// SetMinEvents sets how many events a feed must have had to be guarded, for
+// small feeds whose counts swing naturally
+func (g *ShrinkGuard) SetMinEvents(n int) {
+	g.minEvents = n
+}
+
+// Load reads the counts of a previous run. A missing file is not an error.
+func (g *ShrinkGuard) Load() error {
+	data, err := os.ReadFile(g.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read feed counts: %w", err)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := json.Unmarshal(data, &g.counts); err != nil {
+		return fmt.Errorf("invalid feed counts file %s: %w", g.path, err)
+	}
+	return nil
+}
+
+// Save writes the counts for the next run
+func (g *ShrinkGuard) Save() error {
+	g.mu.Lock()
+	data, err := json.MarshalIndent(g.counts, "", "  ")
+	g.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(g.path), 0755); err != nil {
+		return fmt.Errorf("failed to save feed counts: %w", err)
+	}
+	tmp := g.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to save feed counts: %w", err)
+	}
+	return os.Rename(tmp, g.path)
+}
+
+// check records the event count of a fresh result, or returns a
+// *ShrinkError when it dropped beyond the threshold
+func (g *ShrinkGuard) check(feed Feed, events int) error {
+	if g == nil || g.threshold <= 0 {
+		return nil
+	}
+	sum := sha256.Sum256([]byte(feed.Source))
+	key := hex.EncodeToString(sum[:])
+	now := time.Now()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	prev, ok := g.counts[key]
+	if !ok || prev.Events < g.minEvents || float64(events) >= float64(prev.Events)*(1-g.threshold) {
+		g.counts[key] = feedCount{Events: events, UpdatedAt: now}
+		return nil
+	}
+
+	if prev.DroppedSince.IsZero() {
+		prev.DroppedSince = now
+	}
+	prev.Dropped = events
+	if g.grace > 0 && now.Sub(prev.DroppedSince) >= g.grace {
+		logger.Info("Feed %s has had %d instead of %d events since %s, accepting the new count",
+			feed, events, prev.Events, prev.DroppedSince.Format(time.RFC3339))
+		g.counts[key] = feedCount{Events: events, UpdatedAt: now}
+		return nil
+	}
+	g.counts[key] = prev
+	return &ShrinkError{FeedID: feed.ID, Previous: prev.Events, Current: events}
+}
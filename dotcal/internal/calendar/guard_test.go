@@ -0,0 +1,168 @@
+package calendar
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShrinkGuard(t *testing.T) {
+	feed := Feed{ID: "team", Source: "https://example.com/team.ics"}
+
+	t.Run("thresholds", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			previous  int
+			current   int
+			minEvents int
+			wantErr   bool
+		}{
+			{name: "growth", previous: 300, current: 320},
+			{name: "small drop", previous: 300, current: 200},
+			{name: "collapse", previous: 300, current: 0, wantErr: true},
+			{name: "just over half", previous: 300, current: 149, wantErr: true},
+			{name: "small feed", previous: 9, current: 0, wantErr: true},
+			{name: "single event", previous: 1, current: 0, wantErr: true},
+			{name: "below minimum", previous: 9, current: 0, minEvents: 10},
+			{name: "at minimum", previous: 10, current: 0, minEvents: 10, wantErr: true},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				guard := NewShrinkGuard(filepath.Join(t.TempDir(), "counts.json"))
+				if tt.minEvents > 0 {
+					guard.SetMinEvents(tt.minEvents)
+				}
+				if err := guard.check(feed, tt.previous); err != nil {
+					t.Fatalf("Unexpected error for the first count: %v", err)
+				}
+
+				err := guard.check(feed, tt.current)
+				var serr *ShrinkError
+				if tt.wantErr != errors.As(err, &serr) {
+					t.Fatalf("Expected shrink error %v, got %v", tt.wantErr, err)
+				}
+				if tt.wantErr && (serr.Previous != tt.previous || serr.Current != tt.current) {
+					t.Errorf("Expected %d to %d events, got %+v", tt.previous, tt.current, serr)
+				}
+			})
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		guard := NewShrinkGuard(filepath.Join(t.TempDir(), "counts.json"))
+		guard.SetThreshold(0)
+		guard.check(feed, 300)
+		if err := guard.check(feed, 0); err != nil {
+			t.Errorf("Expected no check with a zero threshold, got %v", err)
+		}
+
+		var nilGuard *ShrinkGuard
+		if err := nilGuard.check(feed, 0); err != nil {
+			t.Errorf("Expected a nil guard to accept everything, got %v", err)
+		}
+	})
+
+	t.Run("grace period", func(t *testing.T) {
+		guard := NewShrinkGuard(filepath.Join(t.TempDir(), "counts.json"))
+		guard.check(feed, 300)
+		if err := guard.check(feed, 10); err == nil {
+			t.Fatal("Expected the drop to be rejected")
+		}
+
+		// Pretend the drop was first seen longer ago than the grace period
+		for key, count := range guard.counts {
+			count.DroppedSince = time.Now().Add(-25 * time.Hour)
+			guard.counts[key] = count
+		}
+		if err := guard.check(feed, 10); err != nil {
+			t.Fatalf("Expected a lasting drop to be accepted, got %v", err)
+		}
+		if err := guard.check(feed, 9); err != nil {
+			t.Errorf("Expected the accepted count to be the new baseline, got %v", err)
+		}
+	})
+
+	t.Run("persists between runs", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "state", "counts.json")
+		guard := NewShrinkGuard(path)
+		if err := guard.Load(); err != nil {
+			t.Fatalf("Expected a missing file to be ignored, got %v", err)
+		}
+		guard.check(feed, 300)
+		if err := guard.Save(); err != nil {
+			t.Fatalf("Failed to save counts: %v", err)
+		}
+
+		next := NewShrinkGuard(path)
+		if err := next.Load(); err != nil {
+			t.Fatalf("Failed to load counts: %v", err)
+		}
+		if err := next.check(feed, 0); err == nil {
+			t.Error("Expected the saved count to be checked against")
+		}
+	})
+}
+
+func TestFetchShrinkGuard(t *testing.T) {
+	parser := NewParser(time.UTC)
+
+	var events int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "BEGIN:VCALENDAR\n")
+		for i := 0; i < events; i++ {
+			fmt.Fprintf(w, "BEGIN:VEVENT\nSUMMARY:Meeting %d\nDTSTART:20250311T090000Z\nDTEND:20250311T100000Z\nEND:VEVENT\n", i)
+		}
+		fmt.Fprint(w, "END:VCALENDAR\n")
+	}))
+	defer server.Close()
+
+	feed := Feed{ID: "team", Source: server.URL, IsURL: true}
+	dir := t.TempDir()
+	newFetcher := func(cache bool) *Fetcher {
+		fetcher := newTestFetcher()
+		if cache {
+			fetcher.SetCacheDir(filepath.Join(dir, "cache"))
+		}
+		guard := NewShrinkGuard(filepath.Join(dir, "counts.json"))
+		guard.Load()
+		fetcher.SetShrinkGuard(guard)
+		return fetcher
+	}
+
+	events = 20
+	fetcher := newFetcher(true)
+	if _, err := fetcher.FetchEvents(feed, parser); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := fetcher.guard.Save(); err != nil {
+		t.Fatalf("Failed to save counts: %v", err)
+	}
+
+	events = 0
+	t.Run("falls back to the cached copy", func(t *testing.T) {
+		result, err := newFetcher(true).FetchEvents(feed, parser)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !result.Stale || len(result.Events) != 20 {
+			t.Errorf("Expected the 20 cached events, got stale=%v with %d events", result.Stale, len(result.Events))
+		}
+		var serr *ShrinkError
+		if !errors.As(result.FetchErr, &serr) {
+			t.Errorf("Expected the shrink to be reported, got %v", result.FetchErr)
+		}
+	})
+
+	t.Run("fails without a cached copy", func(t *testing.T) {
+		_, err := newFetcher(false).FetchEvents(feed, parser)
+		if err == nil || !strings.Contains(err.Error(), "shrank from 20 to 0 events") {
+			t.Errorf("Expected a shrink error, got %v", err)
+		}
+	})
+}
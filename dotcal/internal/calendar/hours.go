@@ -0,0 +1,167 @@
+package calendar
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeRange is a span of a day, given as offsets from midnight
+type TimeRange struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// WorkingHours holds the working time ranges of each weekday. Days without
+// ranges are days off.
+type WorkingHours map[time.Weekday][]TimeRange
+
+// DefaultWorkingHours returns 9 AM to 5 PM, Monday to Friday
+func DefaultWorkingHours() WorkingHours {
+	hours := make(WorkingHours)
+	for day := time.Monday; day <= time.Friday; day++ {
+		hours[day] = []TimeRange{{Start: 9 * time.Hour, End: 17 * time.Hour}}
+	}
+	return hours
+}
+
+// Days returns the working days in ISO week order, Monday first
+func (h WorkingHours) Days() []time.Weekday {
+	var days []time.Weekday
+	for day, ranges := range h {
+		if len(ranges) > 0 {
+			days = append(days, day)
+		}
+	}
+	sort.Slice(days, func(i, j int) bool {
+		return isoWeekdayIndex(days[i]) < isoWeekdayIndex(days[j])
+	})
+	return days
+}
+
+// isoWeekdayIndex returns the number of days since Monday
+func isoWeekdayIndex(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+	"sun": time.Sunday,
+}
+
+// ParseWorkingHours parses working hours such as
+// "mon-thu 07:00-15:00; fri 08:00-12:00,14:00-18:00". Each entry names days,
+// as a range or a comma-separated list, followed by their time ranges or
+// "off". Later entries replace earlier ones for the same day, and days not
+// named are days off.
+func ParseWorkingHours(spec string) (WorkingHours, error) {
+	hours := make(WorkingHours)
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		fields := strings.Fields(entry)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid working hours %q: expected days and time ranges", entry)
+		}
+
+		days, err := parseWeekdays(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid working hours %q: %w", entry, err)
+		}
+		var ranges []TimeRange
+		if !strings.EqualFold(fields[1], "off") {
+			if ranges, err = parseTimeRanges(fields[1]); err != nil {
+				return nil, fmt.Errorf("invalid working hours %q: %w", entry, err)
+			}
+		}
+		for _, day := range days {
+			hours[day] = ranges
+		}
+	}
+
+	if len(hours.Days()) == 0 {
+		return nil, fmt.Errorf("working hours have no working days")
+	}
+	return hours, nil
+}
+
+// parseWeekdays parses "mon-fri" or "mon,wed,fri"
+func parseWeekdays(value string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	for _, part := range strings.Split(strings.ToLower(value), ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		first, ok := weekdayNames[from]
+		if !ok {
+			return nil, fmt.Errorf("unknown day %q", from)
+		}
+		if !isRange {
+			days = append(days, first)
+			continue
+		}
+		last, ok := weekdayNames[to]
+		if !ok {
+			return nil, fmt.Errorf("unknown day %q", to)
+		}
+		if isoWeekdayIndex(last) < isoWeekdayIndex(first) {
+			return nil, fmt.Errorf("day range %q runs backwards", part)
+		}
+		for i := isoWeekdayIndex(first); i <= isoWeekdayIndex(last); i++ {
+			days = append(days, time.Weekday((i+1)%7))
+		}
+	}
+	return days, nil
+}
+
+// parseTimeRanges parses "08:00-12:00,14:00-18:00" into ordered,
+// non-overlapping ranges
+func parseTimeRanges(value string) ([]TimeRange, error) {
+	var ranges []TimeRange
+	for _, part := range strings.Split(value, ",") {
+		from, to, ok := strings.Cut(part, "-")
+		if !ok {
+			return nil, fmt.Errorf("time range %q needs a start and an end", part)
+		}
+		start, err := parseClock(from)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseClock(to)
+		if err != nil {
+			return nil, err
+		}
+		if end <= start {
+			return nil, fmt.Errorf("time range %q ends before it starts", part)
+		}
+		ranges = append(ranges, TimeRange{Start: start, End: end})
+	}
+
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].Start < ranges[j].Start })
+	for i := 1; i < len(ranges); i++ {
+		if ranges[i].Start < ranges[i-1].End {
+			return nil, fmt.Errorf("time ranges %q overlap", value)
+		}
+	}
+	return ranges, nil
+}
+
+// parseClock parses a time of day such as 07:30, allowing 24:00 as the end
+// of the day
+func parseClock(value string) (time.Duration, error) {
+	h, m, ok := strings.Cut(value, ":")
+	hour, herr := strconv.Atoi(h)
+	minute, merr := strconv.Atoi(m)
+	if !ok || herr != nil || merr != nil || hour < 0 || minute < 0 || minute > 59 ||
+		hour > 24 || (hour == 24 && minute != 0) {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute, nil
+}
@@ -0,0 +1,85 @@
+package calendar
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseWorkingHours(t *testing.T) {
+	early := []TimeRange{{Start: 7 * time.Hour, End: 15 * time.Hour}}
+	split := []TimeRange{{Start: 8 * time.Hour, End: 12 * time.Hour}, {Start: 14 * time.Hour, End: 18 * time.Hour}}
+
+	tests := []struct {
+		name     string
+		spec     string
+		expected WorkingHours
+		wantErr  bool
+	}{
+		{
+			name: "four-day week",
+			spec: "mon-thu 07:00-15:00",
+			expected: WorkingHours{
+				time.Monday: early, time.Tuesday: early, time.Wednesday: early, time.Thursday: early,
+			},
+		},
+		{
+			name: "split hours",
+			spec: "MON,wed 14:00-18:00,08:00-12:00",
+			expected: WorkingHours{
+				time.Monday: split, time.Wednesday: split,
+			},
+		},
+		{
+			name: "later entries override",
+			spec: "mon-tue 07:00-15:00; tue off; sun 22:00-24:00",
+			expected: WorkingHours{
+				time.Monday:  early,
+				time.Tuesday: nil,
+				time.Sunday:  {{Start: 22 * time.Hour, End: 24 * time.Hour}},
+			},
+		},
+		{name: "unknown day", spec: "mon-fry 09:00-17:00", wantErr: true},
+		{name: "backwards days", spec: "fri-mon 09:00-17:00", wantErr: true},
+		{name: "backwards range", spec: "mon 17:00-09:00", wantErr: true},
+		{name: "overlapping ranges", spec: "mon 08:00-12:00,11:00-13:00", wantErr: true},
+		{name: "invalid time", spec: "mon 9am-5pm", wantErr: true},
+		{name: "missing ranges", spec: "mon", wantErr: true},
+		{name: "no working days", spec: "mon-fri off", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hours, err := ParseWorkingHours(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for %q", tt.spec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(hours, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, hours)
+			}
+		})
+	}
+}
+
+func TestWorkingHoursDays(t *testing.T) {
+	hours := WorkingHours{
+		time.Sunday:   {{Start: 10 * time.Hour, End: 12 * time.Hour}},
+		time.Friday:   {{Start: 9 * time.Hour, End: 17 * time.Hour}},
+		time.Monday:   {{Start: 9 * time.Hour, End: 17 * time.Hour}},
+		time.Thursday: nil,
+	}
+	expected := []time.Weekday{time.Monday, time.Friday, time.Sunday}
+	if got := hours.Days(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	if got := DefaultWorkingHours().Days(); len(got) != 5 || got[0] != time.Monday || got[4] != time.Friday {
+		t.Errorf("Expected Monday to Friday by default, got %v", got)
+	}
+}
@@ -0,0 +1,200 @@
+package calendar
+
+import (
+	"sort"
+	"time"
+
+	"github.com/zach/dotcal/internal/logger"
+)
+
+// defaultExpansionHorizon bounds recurrence expansion when no window is set
+const defaultExpansionHorizon = 1 // years from now
+
+// vevent holds a parsed VEVENT (or VTODO) together with the properties that
+// are only needed until its instances have been resolved
+type vevent struct {
+	Event
+	line     int  // line of the BEGIN property
+	invalid  bool // contains malformed content and is skipped
+	rrule    string
+	exdates  []time.Time
+	rdates   []period
+	duration *duration // DURATION property, used when DTEND is absent
+
+	// Availability inputs, resolved into Status once the VEVENT is complete
+	status         string            // STATUS value
+	transp         string            // TRANSP value
+	busyStatus     string            // X-MICROSOFT-CDO-BUSYSTATUS value
+	intendedStatus string            // X-MICROSOFT-CDO-INTENDEDSTATUS value
+	attendees      map[string]string // PARTSTAT by attendee email
+	cancelled      bool              // cancelled or declined; suppresses the instance
+
+	// VTODO fields, only set when to-dos are included
+	todo    bool      // parsed from a VTODO rather than a VEVENT
+	due     time.Time // DUE value
+	dueDate bool      // DUE was date-only
+}
+
+// length returns the nominal length of the event. DURATION and all-day
+// events are measured in days so instances keep their wall clock length
+// across DST changes.
+func (v *vevent) length() duration {
+	switch {
+	case v.duration != nil:
+		return *v.duration
+	case v.End.IsZero() || v.End.Before(v.Start):
+		return duration{}
+	case v.AllDay:
+		sy, sm, sd := v.Start.Date()
+		ey, em, ed := v.End.Date()
+		days := time.Date(ey, em, ed, 0, 0, 0, 0, time.UTC).Sub(time.Date(sy, sm, sd, 0, 0, 0, 0, time.UTC))
+		return duration{days: int(days.Hours() / 24)}
+	default:
+		return duration{exact: v.End.Sub(v.Start)}
+	}
+}
+
+// setDefaultEnd fills in End from DURATION or, for date-only events without
+// either, the RFC 5545 default of one day
+func (v *vevent) setDefaultEnd() {
+	if !v.End.IsZero() || v.Start.IsZero() {
+		return
+	}
+	switch {
+	case v.duration != nil:
+		v.End = v.duration.addTo(v.Start)
+	case v.AllDay:
+		v.End = v.Start.AddDate(0, 0, 1)
+	}
+}
+
+// period is an RDATE value; a zero end means the event's own duration applies
+type period struct {
+	start time.Time
+	end   time.Time
+}
+
+// resolve turns the parsed VEVENTs of a feed into concrete events. Recurring
+// events are expanded, excluded instances dropped and overridden instances
+// (VEVENTs sharing a UID with a RECURRENCE-ID) replaced by their modified
+// versions.
+func (p *Parser) resolve(vevents []*vevent) []Event {
+	// Keep only the latest revision of each overridden instance
+	overrides := make(map[string]map[int64]*vevent)
+	var order []*vevent
+	for _, v := range vevents {
+		if v.RecurrenceID.IsZero() || v.UID == "" {
+			order = append(order, v)
+			continue
+		}
+		byInstance, ok := overrides[v.UID]
+		if !ok {
+			byInstance = make(map[int64]*vevent)
+			overrides[v.UID] = byInstance
+		}
+		key := v.RecurrenceID.Unix()
+		if existing, ok := byInstance[key]; ok {
+			if existing.Sequence >= v.Sequence {
+				continue
+			}
+		} else {
+			order = append(order, v)
+		}
+		byInstance[key] = v
+	}
+
+	var events []Event
+	for _, v := range order {
+		if !v.RecurrenceID.IsZero() && v.UID != "" {
+			if latest := overrides[v.UID][v.RecurrenceID.Unix()]; latest != nil && !latest.cancelled {
+				events = append(events, latest.Event)
+			}
+			continue
+		}
+		if v.cancelled {
+			continue
+		}
+
+		for _, instance := range p.expand(v) {
+			if _, overridden := overrides[v.UID][instance.RecurrenceID.Unix()]; overridden {
+				continue
+			}
+			events = append(events, instance)
+		}
+	}
+
+	return events
+}
+
+// expand returns every instance of a recurring event that overlaps the
+// parser's window, or the event itself when it has no recurrence rule
+func (p *Parser) expand(v *vevent) []Event {
+	event := v.Event
+	if (v.rrule == "" && len(v.rdates) == 0) || event.Start.IsZero() {
+		return []Event{event}
+	}
+
+	from, to := p.windowStart, p.windowEnd
+	if to.IsZero() {
+		to = time.Now().AddDate(defaultExpansionHorizon, 0, 0)
+	}
+
+	// Instances that started before the window may still overlap it
+	length := v.length()
+	lookback := length.addTo(event.Start).Sub(event.Start)
+
+	starts := []time.Time{event.Start}
+	if v.rrule != "" {
+		rule, err := ParseRecurrenceRule(v.rrule, event.Start.Location())
+		if err != nil {
+			logger.Debug("ignoring recurrence rule %q: %v", v.rrule, err)
+		} else {
+			starts = rule.Between(event.Start, from.Add(-lookback), to)
+		}
+	}
+
+	seen := make(map[int64]bool)
+	var instances []Event
+	add := func(start, end time.Time) {
+		if seen[start.Unix()] || isExcluded(start, v.exdates) {
+			return
+		}
+		if !start.Before(to) || (start.Before(from) && !end.After(from)) {
+			return
+		}
+		seen[start.Unix()] = true
+
+		instance := event
+		instance.Start = start
+		instance.RecurrenceID = start
+		if !event.End.IsZero() || end.After(start) {
+			instance.End = end
+		}
+		instances = append(instances, instance)
+	}
+
+	for _, start := range starts {
+		add(start, length.addTo(start))
+	}
+	for _, rdate := range v.rdates {
+		end := rdate.end
+		if end.IsZero() {
+			end = length.addTo(rdate.start)
+		}
+		add(rdate.start, end)
+	}
+
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].Start.Before(instances[j].Start)
+	})
+	return instances
+}
+
+func isExcluded(start time.Time, exdates []time.Time) bool {
+	for _, exdate := range exdates {
+		if exdate.Equal(start) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolveRecurrenceSet(t *testing.T) {
+	parser := NewParser(time.UTC)
+	parser.SetWindow(
+		time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC),
+	)
+
+	starts := func(events []Event) []time.Time {
+		var result []time.Time
+		for _, event := range events {
+			result = append(result, event.Start)
+		}
+		return result
+	}
+
+	t.Run("exdate removes instances", func(t *testing.T) {
+		input := `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:standup@example.com
+DTSTART:20250303T090000Z
+DTEND:20250303T091500Z
+RRULE:FREQ=WEEKLY;COUNT=4
+EXDATE:20250310T090000Z,20250317T090000Z
+END:VEVENT
+END:VCALENDAR`
+
+		events, err := parser.Parse([]byte(input))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		got := starts(events)
+		expected := []time.Time{
+			time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC),
+			time.Date(2025, 3, 24, 9, 0, 0, 0, time.UTC),
+		}
+		if len(got) != len(expected) {
+			t.Fatalf("Expected %d instances, got %d: %v", len(expected), len(got), got)
+		}
+		for i := range expected {
+			if !got[i].Equal(expected[i]) {
+				t.Errorf("Instance %d: expected %v, got %v", i, expected[i], got[i])
+			}
+		}
+	})
+
+	t.Run("rdate adds instances", func(t *testing.T) {
+		input := `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:review@example.com
+DTSTART:20250303T130000Z
+DTEND:20250303T140000Z
+RRULE:FREQ=WEEKLY;COUNT=2
+RDATE:20250312T130000Z
+RDATE;VALUE=PERIOD:20250314T100000Z/20250314T120000Z
+RDATE;VALUE=PERIOD:20250320T100000Z/PT30M
+END:VEVENT
+END:VCALENDAR`
+
+		events, err := parser.Parse([]byte(input))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(events) != 5 {
+			t.Fatalf("Expected 5 instances, got %d: %v", len(events), starts(events))
+		}
+		if !events[2].Start.Equal(time.Date(2025, 3, 12, 13, 0, 0, 0, time.UTC)) ||
+			events[2].End.Sub(events[2].Start) != time.Hour {
+			t.Errorf("Expected RDATE instance to keep event duration, got %v - %v", events[2].Start, events[2].End)
+		}
+		if !events[3].End.Equal(time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)) {
+			t.Errorf("Expected period RDATE to use its own end, got %v", events[3].End)
+		}
+		if !events[4].End.Equal(time.Date(2025, 3, 20, 10, 30, 0, 0, time.UTC)) {
+			t.Errorf("Expected period RDATE to use its own duration, got %v", events[4].End)
+		}
+	})
+
+	t.Run("recurrence-id overrides instance", func(t *testing.T) {
+		input := `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:sync@example.com
+DTSTART:20250304T100000Z
+DTEND:20250304T110000Z
+RRULE:FREQ=WEEKLY;COUNT=3
+SUMMARY:Sync
+END:VEVENT
+BEGIN:VEVENT
+UID:sync@example.com
+RECURRENCE-ID:20250311T100000Z
+SEQUENCE:1
+DTSTART:20250312T150000Z
+DTEND:20250312T160000Z
+SUMMARY:Sync (moved)
+END:VEVENT
+BEGIN:VEVENT
+UID:sync@example.com
+RECURRENCE-ID:20250311T100000Z
+SEQUENCE:2
+DTSTART:20250313T150000Z
+DTEND:20250313T160000Z
+SUMMARY:Sync (moved again)
+END:VEVENT
+END:VCALENDAR`
+
+		events, err := parser.Parse([]byte(input))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(events) != 3 {
+			t.Fatalf("Expected 3 events, got %d: %v", len(events), starts(events))
+		}
+
+		var moved *Event
+		for i := range events {
+			if events[i].Start.Equal(time.Date(2025, 3, 11, 10, 0, 0, 0, time.UTC)) {
+				t.Error("Expected overridden instance to be removed")
+			}
+			if events[i].Title == "Sync (moved again)" {
+				moved = &events[i]
+			}
+		}
+		if moved == nil {
+			t.Fatal("Expected override with highest sequence to be kept")
+		}
+		if moved.UID != "sync@example.com" || moved.Sequence != 2 {
+			t.Errorf("Expected override identity to be kept, got UID %q sequence %d", moved.UID, moved.Sequence)
+		}
+	})
+
+	t.Run("override without master is kept", func(t *testing.T) {
+		input := `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:orphan@example.com
+RECURRENCE-ID:20250311T100000Z
+DTSTART:20250311T120000Z
+DTEND:20250311T130000Z
+END:VEVENT
+END:VCALENDAR`
+
+		events, err := parser.Parse([]byte(input))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(events) != 1 {
+			t.Fatalf("Expected 1 event, got %d", len(events))
+		}
+	})
+}
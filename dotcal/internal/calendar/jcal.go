@@ -0,0 +1,142 @@
+package calendar
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// jcalToICS converts a jCal document into iCalendar content lines so it can
+// be read by the same parser as ICS feeds
+func jcalToICS(r io.Reader) ([]byte, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	var doc []any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	// A document is a single vcalendar, though some servers return a list
+	if len(doc) > 0 {
+		if _, ok := doc[0].(string); ok {
+			doc = []any{doc}
+		}
+	}
+
+	w := &icalWriter{}
+	for _, component := range doc {
+		if err := w.jcalComponent(component); err != nil {
+			return nil, err
+		}
+	}
+	return w.buf.Bytes(), nil
+}
+
+// jcalComponent writes a [name, properties, components] array
+func (w *icalWriter) jcalComponent(v any) error {
+	component, ok := v.([]any)
+	if !ok || len(component) != 3 {
+		return fmt.Errorf("invalid component %v", v)
+	}
+	name, ok := component[0].(string)
+	properties, okProps := component[1].([]any)
+	components, okComps := component[2].([]any)
+	if !ok || !okProps || !okComps {
+		return fmt.Errorf("invalid component %v", v)
+	}
+
+	w.line("BEGIN", nil, strings.ToUpper(name))
+	for _, prop := range properties {
+		if err := w.jcalProperty(prop); err != nil {
+			return err
+		}
+	}
+	for _, sub := range components {
+		if err := w.jcalComponent(sub); err != nil {
+			return err
+		}
+	}
+	w.line("END", nil, strings.ToUpper(name))
+	return nil
+}
+
+// jcalProperty writes a [name, parameters, type, value...] array
+func (w *icalWriter) jcalProperty(v any) error {
+	prop, ok := v.([]any)
+	if !ok || len(prop) < 4 {
+		return fmt.Errorf("invalid property %v", v)
+	}
+	name, ok := prop[0].(string)
+	rawParams, okParams := prop[1].(map[string]any)
+	valueType, okType := prop[2].(string)
+	if !ok || !okParams || !okType {
+		return fmt.Errorf("invalid property %v", v)
+	}
+
+	params := make(map[string]string)
+	for param, value := range rawParams {
+		params[param] = jcalValue("", value, ",")
+	}
+	if valueType == "date" {
+		params["value"] = "DATE"
+	}
+
+	values := make([]string, 0, len(prop)-3)
+	for _, value := range prop[3:] {
+		values = append(values, jcalValue(valueType, value, ";"))
+	}
+	w.line(name, params, strings.Join(values, ","))
+	return nil
+}
+
+// jcalValue converts a single jCal value. Arrays are structured values whose
+// parts are joined with sep.
+func jcalValue(valueType string, v any, sep string) string {
+	switch v := v.(type) {
+	case string:
+		return icalValue(valueType, v)
+	case json.Number:
+		return v.String()
+	case bool:
+		return strings.ToUpper(fmt.Sprint(v))
+	case map[string]any:
+		return jcalRecur(v)
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, part := range v {
+			parts = append(parts, jcalValue(valueType, part, ","))
+		}
+		return strings.Join(parts, sep)
+	default:
+		return ""
+	}
+}
+
+// jcalRecur converts a recur object such as {"freq": "WEEKLY", "byday":
+// ["MO", "WE"]} into an RRULE value
+func jcalRecur(rule map[string]any) string {
+	keys := make([]string, 0, len(rule))
+	for key := range rule {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		// FREQ conventionally comes first
+		if (keys[i] == "freq") != (keys[j] == "freq") {
+			return keys[i] == "freq"
+		}
+		return keys[i] < keys[j]
+	})
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		valueType := ""
+		if key == "until" {
+			valueType = "date-time"
+		}
+		parts = append(parts, strings.ToUpper(key)+"="+jcalValue(valueType, rule[key], ","))
+	}
+	return strings.Join(parts, ";")
+}
@@ -0,0 +1,106 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseJCal(t *testing.T) {
+	input := `["vcalendar",
+  [["version", {}, "text", "2.0"]],
+  [
+    ["vevent",
+      [
+        ["uid", {}, "text", "standup@example.com"],
+        ["summary", {}, "text", "Standup; daily, short"],
+        ["dtstart", {"tzid": "America/New_York"}, "date-time", "2025-03-10T09:00:00"],
+        ["duration", {}, "duration", "PT15M"],
+        ["rrule", {}, "recur", {"freq": "DAILY", "count": 3}],
+        ["exdate", {"tzid": "America/New_York"}, "date-time", "2025-03-11T09:00:00"],
+        ["transp", {}, "text", "OPAQUE"]
+      ],
+      [
+        ["valarm", [["trigger", {}, "duration", "-PT5M"]], []]
+      ]
+    ],
+    ["vevent",
+      [
+        ["summary", {}, "text", "Holiday"],
+        ["dtstart", {}, "date", "2025-03-14"]
+      ],
+      []
+    ],
+    ["vfreebusy",
+      [
+        ["freebusy", {"fbtype": "BUSY-TENTATIVE"}, "period", "2025-03-13T14:00:00Z/PT1H"]
+      ],
+      []
+    ]
+  ]
+]`
+
+	parser := NewParser(time.UTC)
+	events, _, err := parser.ParseFeed(Feed{Format: FormatJCal}, []byte(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	nyc, _ := time.LoadLocation("America/New_York")
+	expected := []struct {
+		title  string
+		start  time.Time
+		end    time.Time
+		status Status
+		allDay bool
+	}{
+		{"Holiday", time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC), StatusBusy, true},
+		{"", time.Date(2025, 3, 13, 14, 0, 0, 0, time.UTC), time.Date(2025, 3, 13, 15, 0, 0, 0, time.UTC), StatusTentative, false},
+		{"Standup; daily, short", time.Date(2025, 3, 10, 9, 0, 0, 0, nyc), time.Date(2025, 3, 10, 9, 15, 0, 0, nyc), StatusBusy, false},
+		{"Standup; daily, short", time.Date(2025, 3, 12, 9, 0, 0, 0, nyc), time.Date(2025, 3, 12, 9, 15, 0, 0, nyc), StatusBusy, false},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, got %d: %v", len(expected), len(events), events)
+	}
+	for i, e := range expected {
+		got := events[i]
+		if got.Title != e.title || !got.Start.Equal(e.start) || !got.End.Equal(e.end) || got.Status != e.status || got.AllDay != e.allDay {
+			t.Errorf("Event %d: expected %s %v-%v %s allDay=%v, got %s %v-%v %s allDay=%v",
+				i, e.title, e.start, e.end, e.status, e.allDay, got.Title, got.Start, got.End, got.Status, got.AllDay)
+		}
+	}
+}
+
+func TestJCalToICS(t *testing.T) {
+	t.Run("list of calendars", func(t *testing.T) {
+		input := `[["vcalendar", [], []], ["vcalendar", [], []]]`
+		data, err := jcalToICS(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if strings.Count(string(data), "BEGIN:VCALENDAR") != 2 {
+			t.Errorf("Expected two calendars, got %q", data)
+		}
+	})
+
+	t.Run("recur with until and lists", func(t *testing.T) {
+		input := `["vcalendar", [], [["vevent", [
+			["rrule", {}, "recur", {"byday": ["MO", "WE"], "freq": "WEEKLY", "until": "2025-04-01T00:00:00Z"}]
+		], []]]]`
+		data, err := jcalToICS(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		expected := "RRULE:FREQ=WEEKLY;BYDAY=MO,WE;UNTIL=20250401T000000Z\r\n"
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("Expected %q in %q", expected, data)
+		}
+	})
+
+	t.Run("invalid property", func(t *testing.T) {
+		input := `["vcalendar", [["summary", {}]], []]`
+		if _, err := jcalToICS(strings.NewReader(input)); err == nil {
+			t.Error("Expected error for incomplete property")
+		}
+	})
+}
@@ -0,0 +1,199 @@
+package calendar
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+)
+
+// utf8BOM is stripped from the start of a feed
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// property is a content line split into its name, parameters and value
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+	line   int    // physical line the property starts on
+	raw    string // unfolded content line
+
+	malformed bool // no name or no ':' separating the value
+}
+
+// text returns the value unescaped as an RFC 5545 TEXT value
+func (prop property) text() string {
+	return unescapeText(strings.TrimSpace(prop.value))
+}
+
+// lexer reads the content lines of an iCalendar stream, unfolding them and
+// splitting each into a property
+type lexer struct {
+	r    *bufio.Reader
+	line int
+}
+
+// newLexer creates a lexer reading from r
+func newLexer(r io.Reader) *lexer {
+	return &lexer{r: bufio.NewReader(r)}
+}
+
+// next returns the next non-empty content line, or io.EOF once the input is
+// exhausted
+func (l *lexer) next() (property, error) {
+	for {
+		raw, start, err := l.unfold()
+		if err != nil {
+			return property{}, err
+		}
+		if len(bytes.TrimSpace(raw)) == 0 {
+			continue
+		}
+
+		// Folding may split multi-byte characters; they are only decoded
+		// after the physical lines have been joined
+		line := strings.ToValidUTF8(string(raw), "�")
+		prop := parseProperty(line)
+		prop.line = start
+		prop.raw = line
+		return prop, nil
+	}
+}
+
+// unfold joins a physical line with the continuation lines following it.
+// A continuation starts with a single space or tab which is removed; nothing
+// is inserted in its place.
+func (l *lexer) unfold() ([]byte, int, error) {
+	raw, err := l.physicalLine()
+	if err != nil {
+		return nil, 0, err
+	}
+	start := l.line
+	if start == 1 {
+		raw = bytes.TrimPrefix(raw, utf8BOM)
+	}
+
+	for {
+		b, err := l.r.Peek(1)
+		if err != nil || (b[0] != ' ' && b[0] != '\t') {
+			return raw, start, nil
+		}
+		l.r.Discard(1)
+		cont, err := l.physicalLine()
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, 0, err
+		}
+		raw = append(raw, cont...)
+	}
+}
+
+// physicalLine reads one line terminated by CRLF, LF or the end of input
+func (l *lexer) physicalLine() ([]byte, error) {
+	raw, err := l.r.ReadBytes('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || len(raw) == 0) {
+		return nil, err
+	}
+	l.line++
+	raw = bytes.TrimSuffix(raw, []byte("\n"))
+	raw = bytes.TrimSuffix(raw, []byte("\r"))
+	return raw, nil
+}
+
+// parseProperty splits a content line such as
+// DTSTART;TZID="Europe/Berlin":20250310T090000 into its parts. Parameter
+// names are upper-cased and quoted parameter values may contain ':' and ';'.
+// A parameter with several values keeps them joined by commas.
+func parseProperty(line string) property {
+	prop := property{params: make(map[string]string)}
+
+	i := strings.IndexAny(line, ";:")
+	if i < 0 {
+		prop.name = strings.ToUpper(strings.TrimSpace(line))
+		prop.malformed = true
+		return prop
+	}
+	prop.name = strings.ToUpper(strings.TrimSpace(line[:i]))
+	prop.malformed = true // until the ':' before the value is found
+
+	for line[i] == ';' {
+		rest := line[i+1:]
+		eq := strings.IndexByte(rest, '=')
+		if eq < 0 {
+			break
+		}
+		name := strings.ToUpper(rest[:eq])
+		value, j, ok := paramValue(rest, eq+1)
+		if !ok {
+			break
+		}
+		prop.params[name] = value
+		i += 1 + j
+		if i >= len(line) {
+			return prop
+		}
+	}
+
+	if line[i] == ':' {
+		prop.value = line[i+1:]
+		prop.malformed = prop.name == ""
+	}
+	return prop
+}
+
+// paramValue reads the comma-separated values of a parameter starting at
+// rest[j], each of which may be quoted, and returns them with the offset of
+// the ';' or ':' following them
+func paramValue(rest string, j int) (string, int, bool) {
+	var values []string
+	for {
+		if j < len(rest) && rest[j] == '"' {
+			end := strings.IndexByte(rest[j+1:], '"')
+			if end < 0 {
+				return "", 0, false
+			}
+			values = append(values, rest[j+1:j+1+end])
+			j += end + 2
+		} else {
+			end := strings.IndexAny(rest[j:], ",;:")
+			if end < 0 {
+				return "", 0, false
+			}
+			values = append(values, rest[j:j+end])
+			j += end
+		}
+		if j >= len(rest) || rest[j] != ',' {
+			return strings.Join(values, ","), j, true
+		}
+		j++
+	}
+}
+
+// unescapeText resolves the backslash escapes of a TEXT value
+func unescapeText(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+
+	var b strings.Builder
+	b.Grow(len(value))
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c != '\\' || i+1 == len(value) {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch value[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		case '\\', ';', ',':
+			b.WriteByte(value[i])
+		default:
+			// Unknown escapes are kept as they are
+			b.WriteByte('\\')
+			b.WriteByte(value[i])
+		}
+	}
+	return b.String()
+}
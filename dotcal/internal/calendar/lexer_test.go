@@ -0,0 +1,156 @@
+package calendar
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLexer(t *testing.T) {
+	lexAll := func(t *testing.T, input string) []property {
+		t.Helper()
+		lex := newLexer(strings.NewReader(input))
+		var props []property
+		for {
+			prop, err := lex.next()
+			if errors.Is(err, io.EOF) {
+				return props
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			props = append(props, prop)
+		}
+	}
+
+	t.Run("crlf and lf line endings", func(t *testing.T) {
+		props := lexAll(t, "BEGIN:VEVENT\r\nSUMMARY:One\nEND:VEVENT")
+		if len(props) != 3 {
+			t.Fatalf("Expected 3 properties, got %d", len(props))
+		}
+		if props[1].value != "One" || props[2].value != "VEVENT" {
+			t.Errorf("Expected line endings to be stripped, got %q and %q", props[1].value, props[2].value)
+		}
+	})
+
+	t.Run("folding inserts nothing", func(t *testing.T) {
+		props := lexAll(t, "URL:https://example.com/cal\r\n endar/feed\r\n\t.ics\r\n")
+		if len(props) != 1 {
+			t.Fatalf("Expected 1 property, got %d", len(props))
+		}
+		if props[0].value != "https://example.com/calendar/feed.ics" {
+			t.Errorf("Expected unfolded URL, got %q", props[0].value)
+		}
+	})
+
+	t.Run("fold inside multi-byte character", func(t *testing.T) {
+		// "é" is 0xC3 0xA9 and is split across the fold
+		props := lexAll(t, "SUMMARY:Caf\xC3\r\n \xA9 meeting\r\n")
+		if len(props) != 1 || props[0].text() != "Café meeting" {
+			t.Errorf("Expected Café meeting, got %v", props)
+		}
+	})
+
+	t.Run("byte order mark is stripped", func(t *testing.T) {
+		props := lexAll(t, "\xEF\xBB\xBFBEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n")
+		if len(props) != 2 || props[0].name != "BEGIN" || props[0].value != "VCALENDAR" {
+			t.Errorf("Expected BEGIN:VCALENDAR, got %v", props)
+		}
+	})
+
+	t.Run("line numbers", func(t *testing.T) {
+		props := lexAll(t, "BEGIN:VEVENT\r\nDESCRIPTION:a\r\n b\r\n\r\nEND:VEVENT\r\n")
+		if len(props) != 3 {
+			t.Fatalf("Expected 3 properties, got %d", len(props))
+		}
+		for i, expected := range []int{1, 2, 5} {
+			if props[i].line != expected {
+				t.Errorf("Property %d: expected line %d, got %d", i, expected, props[i].line)
+			}
+		}
+	})
+}
+
+func TestParseProperty(t *testing.T) {
+	prop := parseProperty(`ATTENDEE;CN="Doe, Jane: PM";PARTSTAT=ACCEPTED:mailto:jane@example.com`)
+	if prop.name != "ATTENDEE" {
+		t.Errorf("Expected name ATTENDEE, got %s", prop.name)
+	}
+	if prop.params["CN"] != "Doe, Jane: PM" {
+		t.Errorf("Expected quoted CN, got %q", prop.params["CN"])
+	}
+	if prop.params["PARTSTAT"] != "ACCEPTED" {
+		t.Errorf("Expected PARTSTAT ACCEPTED, got %q", prop.params["PARTSTAT"])
+	}
+	if prop.value != "mailto:jane@example.com" {
+		t.Errorf("Expected value with colon, got %q", prop.value)
+	}
+}
+
+func TestParsePropertyValueLists(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		param    string
+		expected string
+		value    string
+	}{
+		{
+			name:     "quoted values",
+			line:     `ATTENDEE;DELEGATED-FROM="mailto:a@x.com","mailto:b@x.com":mailto:c@x.com`,
+			param:    "DELEGATED-FROM",
+			expected: "mailto:a@x.com,mailto:b@x.com",
+			value:    "mailto:c@x.com",
+		},
+		{
+			name:     "quoted and unquoted values",
+			line:     `ATTENDEE;MEMBER="mailto:list@x.com",group;ROLE=CHAIR:mailto:c@x.com`,
+			param:    "MEMBER",
+			expected: "mailto:list@x.com,group",
+			value:    "mailto:c@x.com",
+		},
+		{
+			name:     "unquoted values",
+			line:     `X-LIST;TYPES=a,b,c:value`,
+			param:    "TYPES",
+			expected: "a,b,c",
+			value:    "value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prop := parseProperty(tt.line)
+			if prop.malformed {
+				t.Fatalf("Expected %q to be well-formed", tt.line)
+			}
+			if prop.params[tt.param] != tt.expected {
+				t.Errorf("Expected %s %q, got %q", tt.param, tt.expected, prop.params[tt.param])
+			}
+			if prop.value != tt.value {
+				t.Errorf("Expected value %q, got %q", tt.value, prop.value)
+			}
+		})
+	}
+}
+
+func TestUnescapeText(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{`plain`, "plain"},
+		{`Room 4\, 2nd floor`, "Room 4, 2nd floor"},
+		{`a\;b`, "a;b"},
+		{`line one\nline two\Nthree`, "line one\nline two\nthree"},
+		{`C:\\temp`, `C:\temp`},
+		{`trailing\`, `trailing\`},
+	}
+
+	for _, tc := range tests {
+		if got := unescapeText(tc.value); got != tc.expected {
+			t.Errorf("unescapeText(%q) = %q; want %q", tc.value, got, tc.expected)
+		}
+	}
+}
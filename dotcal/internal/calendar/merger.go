@@ -7,9 +7,26 @@ import (
 	"github.com/zach/dotcal/internal/logger"
 )
 
+// AllDayMode controls how all-day events affect the schedule
+type AllDayMode string
+
+const (
+	// AllDayBusy blocks every slot of each day an all-day event covers
+	AllDayBusy AllDayMode = "busy"
+	// AllDayAnnotate lists all-day events on their days without blocking slots
+	AllDayAnnotate AllDayMode = "annotate"
+)
+
+// DefaultSlotLength is the length of schedule slots unless the merger sets
+// its own
+const DefaultSlotLength = 30 * time.Minute
+
 // Merger handles merging multiple calendars into a unified schedule
 type Merger struct {
-	timezone *time.Location
+	timezone     *time.Location
+	allDayMode   AllDayMode
+	workingHours WorkingHours
+	slotLength   time.Duration
 }
 
 // NewMerger creates a new calendar merger
@@ -17,21 +34,46 @@ func NewMerger(timezone *time.Location) *Merger {
 	if timezone == nil {
 		timezone = time.UTC
 	}
-	return &Merger{timezone: timezone}
+	return &Merger{
+		timezone:     timezone,
+		allDayMode:   AllDayBusy,
+		workingHours: DefaultWorkingHours(),
+		slotLength:   DefaultSlotLength,
+	}
+}
+
+// SetAllDayMode sets whether all-day events block slots or only annotate days
+func (m *Merger) SetAllDayMode(mode AllDayMode) {
+	m.allDayMode = mode
+}
+
+// SetWorkingHours sets the days and hours the schedule shows slots for
+func (m *Merger) SetWorkingHours(hours WorkingHours) {
+	m.workingHours = hours
+}
+
+// SetSlotLength sets the length of the schedule's slots, such as 15 minutes
+// or an hour. Lengths that are not positive are ignored.
+func (m *Merger) SetSlotLength(length time.Duration) {
+	if length > 0 {
+		m.slotLength = length
+	}
 }
 
 // MergeEvents combines multiple event lists into a unified weekly schedule
 func (m *Merger) MergeEvents(events []Event, year int, week int) *WeekSchedule {
 	schedule := &WeekSchedule{
-		Year:     year,
-		Week:     week,
-		TimeZone: m.timezone,
-		Days:     make(map[time.Weekday][]TimeSlot),
+		Year:       year,
+		Week:       week,
+		TimeZone:   m.timezone,
+		SlotLength: m.slotLength,
+		Days:       make(map[time.Weekday][]TimeSlot),
+		AllDay:     make(map[time.Weekday][]Event),
 	}
 
-	// Initialize empty slots for each day
-	for day := time.Monday; day <= time.Friday; day++ {
-		schedule.Days[day] = m.createDaySlots()
+	// Initialize empty slots for each working day
+	for _, day := range m.workingHours.Days() {
+		schedule.Days[day] = m.createDaySlots(m.workingHours[day])
 	}
 
 	// Calculate the start and end dates of the specified week
@@ -44,12 +86,8 @@ func (m *Merger) MergeEvents(events []Event, year int, week int) *WeekSchedule {
 	// Filter events to only include those within the specified week
 	weekEvents := make([]Event, 0)
 	for _, event := range events {
-		// Skip weekend events
-		if event.Start.Weekday() == time.Saturday || event.Start.Weekday() == time.Sunday {
-			continue
-		}
-
-		// Check if event falls within the week
+		// Check if event overlaps the week; events spanning several days
+		// are painted onto each weekday they cover
 		if event.Start.Before(weekEnd) && event.End.After(weekStart) {
 			weekEvents = append(weekEvents, event)
 		}
@@ -71,25 +109,44 @@ func (m *Merger) MergeEvents(events []Event, year int, week int) *WeekSchedule {
 	return schedule
 }
 
-// createDaySlots creates empty 30-minute slots for a day (9 AM to 5 PM)
-func (m *Merger) createDaySlots() []TimeSlot {
-	slots := make([]TimeSlot, 0, 16) // 8 hours * 2 slots per hour
+// createDaySlots creates empty slots of the merger's slot length covering a
+// day's working ranges. A range that is not a whole number of slots ends
+// with a shorter one.
+func (m *Merger) createDaySlots(ranges []TimeRange) []TimeSlot {
+	var slots []TimeSlot
 
 	// Use a reference date for the slots (actual year/month/day will be set during merge)
-	start := time.Date(2000, 1, 1, 9, 0, 0, 0, m.timezone)
-
-	for i := 0; i < 16; i++ {
-		slotStart := start.Add(time.Duration(i) * 30 * time.Minute)
-		slots = append(slots, TimeSlot{
-			Start:  slotStart,
-			End:    slotStart.Add(30 * time.Minute),
-			Status: StatusAvailable,
-		})
+	midnight := time.Date(2000, 1, 1, 0, 0, 0, 0, m.timezone)
+
+	for _, r := range ranges {
+		for offset := r.Start; offset < r.End; offset += m.slotLength {
+			slotEnd := min(offset+m.slotLength, r.End)
+			slots = append(slots, TimeSlot{
+				Start:  midnight.Add(offset),
+				End:    midnight.Add(slotEnd),
+				Status: StatusAvailable,
+			})
+		}
 	}
 
 	return slots
 }
 
+// statusPriority ranks statuses for overlapping events:
+// available < tentative < busy < out of office
+func statusPriority(status Status) int {
+	switch status {
+	case StatusTentative:
+		return 1
+	case StatusBusy:
+		return 2
+	case StatusOutOfOffice:
+		return 3
+	default:
+		return 0
+	}
+}
+
 // FirstDayOfISOWeek returns the date of the first day (Monday) of the given ISO week
 func FirstDayOfISOWeek(year int, week int, loc *time.Location) time.Time {
 	// Start with January 4th which is always in week 1 of the ISO week year
@@ -106,64 +163,49 @@ func FirstDayOfISOWeek(year int, week int, loc *time.Location) time.Time {
 	return mon1.AddDate(0, 0, (week-1)*7)
 }
 
-// mergeEventIntoSchedule merges a single event into the schedule
+// mergeEventIntoSchedule merges a single event into every day of the schedule it covers
 func (m *Merger) mergeEventIntoSchedule(schedule *WeekSchedule, event Event) {
 	logger.Debug("processing event: ", event, " with status: ", event.Status)
 
-	// Skip events outside business hours or on weekends
-	if event.Start.Weekday() == time.Saturday || event.Start.Weekday() == time.Sunday {
-		logger.Debug("skipping weekend event")
-		return
-	}
-
-	// Calculate the start and end dates of the specified week
+	// Calculate the start date of the specified week
 	// ISO week starts on Monday and ends on Sunday
-	year, week := schedule.Year, schedule.Week
-	weekStart := FirstDayOfISOWeek(year, week, m.timezone)
-	weekEnd := weekStart.AddDate(0, 0, 7) // End of week (exclusive)
+	weekStart := FirstDayOfISOWeek(schedule.Year, schedule.Week, m.timezone)
 
-	// Skip events outside the specified week
-	if event.Start.Before(weekStart) || event.Start.After(weekEnd) || event.Start.Equal(weekEnd) {
-		logger.Debug("skipping event outside specified week")
-		return
-	}
+	for day, daySlots := range schedule.Days {
+		dayStart := weekStart.AddDate(0, 0, isoWeekdayIndex(day))
+		dayEnd := dayStart.AddDate(0, 0, 1)
+
+		// Skip days the event does not cover
+		if !event.Start.Before(dayEnd) || !event.End.After(dayStart) {
+			continue
+		}
+
+		if event.AllDay && m.allDayMode == AllDayAnnotate {
+			logger.Debug("annotating all-day event on %s", day)
+			schedule.AllDay[day] = append(schedule.AllDay[day], event)
+			continue
+		}
 
-	daySlots := schedule.Days[event.Start.Weekday()]
-	eventDate := time.Date(event.Start.Year(), event.Start.Month(), event.Start.Day(), 0, 0, 0, 0, m.timezone)
-
-	for i := range daySlots {
-		slot := &daySlots[i]
-
-		// Adjust slot times to match event date
-		slotStart := time.Date(
-			eventDate.Year(), eventDate.Month(), eventDate.Day(),
-			slot.Start.Hour(), slot.Start.Minute(), 0, 0,
-			m.timezone,
-		)
-		slotEnd := slotStart.Add(30 * time.Minute)
-
-		// logger.Debug("event: ", event, " status: ", event.Status)
-		// logger.Debug("slot before: ", slot)
-		// logger.Debug("adjusted slot time - start: ", slotStart, " end: ", slotEnd)
-
-		// Check if event overlaps with this slot using adjusted times
-		if event.Start.Before(slotEnd) && event.End.After(slotStart) {
-			logger.Debug("overlap detected with status: ", event.Status)
-			// Update slot based on status priority
-			switch {
-			case event.Status == StatusBusy:
-				// Busy always takes precedence
-				slot.Status = StatusBusy
-				slot.Original = &event
-			case event.Status == StatusTentative && slot.Status != StatusBusy:
-				// Tentative takes precedence over Available
-				slot.Status = StatusTentative
-				slot.Original = &event
-			case event.Status == StatusAvailable:
-				// For Available events, always update the Original reference
-				// but keep the slot's current status
-				slot.Original = &event
-				slot.Status = StatusAvailable
+		for i := range daySlots {
+			slot := &daySlots[i]
+
+			// Adjust slot times to match the day being painted
+			slotStart := time.Date(
+				dayStart.Year(), dayStart.Month(), dayStart.Day(),
+				slot.Start.Hour(), slot.Start.Minute(), 0, 0,
+				m.timezone,
+			)
+			slotEnd := slotStart.Add(slot.End.Sub(slot.Start))
+
+			// Check if event overlaps with this slot using adjusted times
+			if event.Start.Before(slotEnd) && event.End.After(slotStart) {
+				logger.Debug("overlap detected with status: ", event.Status)
+				// Update slot based on status priority; an event never
+				// downgrades a slot already claimed by a stronger status
+				if statusPriority(event.Status) >= statusPriority(slot.Status) {
+					slot.Status = event.Status
+					slot.Original = &event
+				}
 			}
 		}
 	}
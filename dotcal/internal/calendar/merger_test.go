@@ -165,4 +165,225 @@ func TestMergeEvents(t *testing.T) {
 			}
 		}
 	})
+	t.Run("available event does not free a busy slot", func(t *testing.T) {
+		events := []Event{
+			{
+				Start:  baseDate.Add(10 * time.Hour),
+				End:    baseDate.Add(11 * time.Hour),
+				Status: StatusBusy,
+			},
+			{
+				Start:  baseDate.Add(10*time.Hour + 30*time.Minute),
+				End:    baseDate.Add(11 * time.Hour),
+				Status: StatusAvailable,
+			},
+		}
+		schedule := merger.MergeEvents(events, 2025, 9)
+
+		if slot := schedule.Days[time.Monday][3]; slot.Status != StatusBusy {
+			t.Errorf("Expected busy status to be kept, got %v", slot.Status)
+		}
+	})
+
+	t.Run("out of office outranks busy", func(t *testing.T) {
+		events := []Event{
+			{
+				Start:  baseDate.Add(9 * time.Hour),
+				End:    baseDate.Add(17 * time.Hour),
+				Status: StatusOutOfOffice,
+			},
+			{
+				Start:  baseDate.Add(10 * time.Hour),
+				End:    baseDate.Add(11 * time.Hour),
+				Status: StatusBusy,
+			},
+		}
+		schedule := merger.MergeEvents(events, 2025, 9)
+
+		for i, slot := range schedule.Days[time.Monday] {
+			if slot.Status != StatusOutOfOffice {
+				t.Errorf("Expected out of office status for slot %d, got %v", i, slot.Status)
+			}
+		}
+	})
+
+	t.Run("multi-day event blocks every covered day", func(t *testing.T) {
+		events := []Event{
+			{
+				Start:  baseDate.Add(13 * time.Hour),                  // Monday 1 PM
+				End:    baseDate.AddDate(0, 0, 2).Add(12 * time.Hour), // Wednesday noon
+				Status: StatusBusy,
+			},
+		}
+		schedule := merger.MergeEvents(events, 2025, 9)
+
+		if schedule.Days[time.Monday][3].Status != StatusAvailable {
+			t.Error("Expected Monday morning to stay available")
+		}
+		if schedule.Days[time.Monday][8].Status != StatusBusy {
+			t.Error("Expected Monday afternoon to be busy")
+		}
+		for _, slot := range schedule.Days[time.Tuesday] {
+			if slot.Status != StatusBusy {
+				t.Errorf("Expected all of Tuesday to be busy, got %v", slot.Status)
+			}
+		}
+		if schedule.Days[time.Wednesday][5].Status != StatusBusy {
+			t.Error("Expected Wednesday morning to be busy")
+		}
+		if schedule.Days[time.Wednesday][6].Status != StatusAvailable {
+			t.Error("Expected Wednesday afternoon to be available")
+		}
+		if schedule.Days[time.Thursday][0].Status != StatusAvailable {
+			t.Error("Expected Thursday to be unaffected")
+		}
+	})
+
+	t.Run("event starting on weekend covers following weekdays", func(t *testing.T) {
+		events := []Event{
+			{
+				Start:  baseDate.AddDate(0, 0, -2), // Saturday before
+				End:    baseDate.AddDate(0, 0, 1),  // Through Monday
+				Status: StatusBusy,
+				AllDay: true,
+			},
+		}
+		schedule := merger.MergeEvents(events, 2025, 9)
+
+		for _, slot := range schedule.Days[time.Monday] {
+			if slot.Status != StatusBusy {
+				t.Errorf("Expected Monday to be busy, got %v", slot.Status)
+			}
+		}
+		if schedule.Days[time.Tuesday][0].Status != StatusAvailable {
+			t.Error("Expected exclusive end date to leave Tuesday available")
+		}
+	})
+
+	t.Run("all-day events can annotate instead of block", func(t *testing.T) {
+		annotating := NewMerger(time.UTC)
+		annotating.SetAllDayMode(AllDayAnnotate)
+
+		events := []Event{
+			{
+				Start:  baseDate.AddDate(0, 0, 3), // Thursday
+				End:    baseDate.AddDate(0, 0, 5), // Through Friday
+				Status: StatusBusy,
+				AllDay: true,
+			},
+		}
+		schedule := annotating.MergeEvents(events, 2025, 9)
+
+		for _, day := range []time.Weekday{time.Thursday, time.Friday} {
+			if len(schedule.AllDay[day]) != 1 {
+				t.Errorf("Expected one all-day annotation on %v, got %d", day, len(schedule.AllDay[day]))
+			}
+			for _, slot := range schedule.Days[day] {
+				if slot.Status != StatusAvailable {
+					t.Errorf("Expected %v slots to stay available, got %v", day, slot.Status)
+				}
+			}
+		}
+		if len(schedule.AllDay[time.Wednesday]) != 0 {
+			t.Error("Expected no annotation on Wednesday")
+		}
+	})
+
+	t.Run("working hours", func(t *testing.T) {
+		hours, err := ParseWorkingHours("mon-wed 08:00-12:00,14:00-18:15; thu 07:00-15:00")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		custom := NewMerger(time.UTC)
+		custom.SetWorkingHours(hours)
+
+		events := []Event{
+			{
+				Start:  baseDate.Add(11*time.Hour + 30*time.Minute), // Monday 11:30 AM
+				End:    baseDate.Add(15 * time.Hour),                // through lunch
+				Status: StatusBusy,
+			},
+			{
+				Start:  baseDate.AddDate(0, 0, 4).Add(10 * time.Hour), // Friday, a day off
+				End:    baseDate.AddDate(0, 0, 4).Add(11 * time.Hour),
+				Status: StatusBusy,
+			},
+		}
+		schedule := custom.MergeEvents(events, 2025, 9)
+
+		if len(schedule.Days) != 4 {
+			t.Errorf("Expected 4 working days, got %d", len(schedule.Days))
+		}
+		if _, ok := schedule.Days[time.Friday]; ok {
+			t.Error("Expected no slots on a day off")
+		}
+
+		monday := schedule.Days[time.Monday]
+		// 8 morning slots, 8 afternoon slots and a final 15-minute slot
+		if len(monday) != 17 {
+			t.Fatalf("Expected 17 slots on Monday, got %d", len(monday))
+		}
+		if monday[8].Start.Hour() != 14 {
+			t.Errorf("Expected the afternoon to start at 2 PM, got %v", monday[8].Start)
+		}
+		if last := monday[16]; last.End.Sub(last.Start) != 15*time.Minute {
+			t.Errorf("Expected a shortened last slot, got %v - %v", last.Start, last.End)
+		}
+		busy := 0
+		for _, slot := range monday {
+			if slot.Status == StatusBusy {
+				busy++
+			}
+		}
+		// 11:30-12:00 and 14:00-15:00
+		if busy != 3 {
+			t.Errorf("Expected 3 busy slots on Monday, got %d", busy)
+		}
+
+		if thursday := schedule.Days[time.Thursday]; len(thursday) != 16 || thursday[0].Start.Hour() != 7 {
+			t.Errorf("Expected Thursday to run from 7 AM in 16 slots, got %d slots", len(thursday))
+		}
+	})
+
+	t.Run("slot length", func(t *testing.T) {
+		event := Event{
+			Start:  baseDate.Add(10 * time.Hour),                // 10 AM
+			End:    baseDate.Add(10*time.Hour + 20*time.Minute), // 10:20 AM
+			Status: StatusBusy,
+		}
+
+		tests := []struct {
+			length time.Duration
+			slots  int
+			busy   int
+		}{
+			{length: 15 * time.Minute, slots: 32, busy: 2},
+			{length: time.Hour, slots: 8, busy: 1},
+		}
+		for _, tt := range tests {
+			custom := NewMerger(time.UTC)
+			custom.SetSlotLength(tt.length)
+			schedule := custom.MergeEvents([]Event{event}, 2025, 9)
+
+			if schedule.SlotLength != tt.length {
+				t.Errorf("Expected slot length %v, got %v", tt.length, schedule.SlotLength)
+			}
+			monday := schedule.Days[time.Monday]
+			if len(monday) != tt.slots {
+				t.Fatalf("Expected %d slots of %v, got %d", tt.slots, tt.length, len(monday))
+			}
+			busy := 0
+			for _, slot := range monday {
+				if slot.End.Sub(slot.Start) != tt.length {
+					t.Errorf("Expected %v slots, got %v - %v", tt.length, slot.Start, slot.End)
+				}
+				if slot.Status == StatusBusy {
+					busy++
+				}
+			}
+			if busy != tt.busy {
+				t.Errorf("Expected %d busy slots of %v, got %d", tt.busy, tt.length, busy)
+			}
+		}
+	})
 }
@@ -1,13 +1,26 @@
 package calendar
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/zach/dotcal/internal/logger"
 )
 
 // Parser handles parsing ICS calendar data
 type Parser struct {
-	timezone *time.Location
+	mode           ParseMode
+	timezone       *time.Location
+	windowStart    time.Time
+	windowEnd      time.Time
+	ownerEmails    map[string]bool
+	titleHeuristic bool
+	includeTodos   bool
 }
 
 // NewParser creates a new calendar parser
@@ -15,112 +28,574 @@ func NewParser(timezone *time.Location) *Parser {
 	if timezone == nil {
 		timezone = time.UTC
 	}
-	return &Parser{timezone: timezone}
+	return &Parser{timezone: timezone, mode: ParseLenient}
+}
+
+// SetMode sets whether malformed events are skipped or fail the whole feed
+func (p *Parser) SetMode(mode ParseMode) {
+	p.mode = mode
+}
+
+// SetWindow limits recurrence expansion to instances overlapping [start, end).
+// This should match the range of weeks being rendered.
+func (p *Parser) SetWindow(start, end time.Time) {
+	p.windowStart = start
+	p.windowEnd = end
+}
+
+// SetOwnerEmails sets the calendar owner's addresses. An ATTENDEE matching
+// one of them decides whether the owner declined or has yet to respond.
+func (p *Parser) SetOwnerEmails(emails []string) {
+	p.ownerEmails = make(map[string]bool)
+	for _, email := range emails {
+		if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+			p.ownerEmails[email] = true
+		}
+	}
+}
+
+// SetIncludeTodos makes VTODOs with a due time block time before it is due
+func (p *Parser) SetIncludeTodos(include bool) {
+	p.includeTodos = include
+}
+
+// SetTitleHeuristic enables the legacy rule that marks any event with "busy"
+// in its title as busy, for feeds that only publish availability in titles
+func (p *Parser) SetTitleHeuristic(enabled bool) {
+	p.titleHeuristic = enabled
+}
+
+// parseState holds the per-feed context needed while parsing
+type parseState struct {
+	feedID    string
+	floating  *time.Location            // zone for floating date-times
+	timezones map[string]*time.Location // zones defined by VTIMEZONE components
+	errors    []*ParseError
+}
+
+// report records a property that could not be parsed
+func (st *parseState) report(prop property, err error) {
+	logger.Debug("feed %s line %d: skipping %s: %v", st.feedID, prop.line, prop.name, err)
+	st.errors = append(st.errors, &ParseError{
+		FeedID:   st.feedID,
+		Line:     prop.line,
+		Property: prop.name,
+		Raw:      prop.raw,
+		Err:      err,
+	})
 }
 
-// Parse parses raw ICS data into events
+// warn records a property that is well-formed but only partly supported
+func (st *parseState) warn(prop property, err error) {
+	logger.Debug("feed %s line %d: %s not fully supported: %v", st.feedID, prop.line, prop.name, err)
+	st.errors = append(st.errors, &ParseError{
+		FeedID:   st.feedID,
+		Line:     prop.line,
+		Property: prop.name,
+		Raw:      prop.raw,
+		Err:      err,
+		Warning:  true,
+	})
+}
+
+// Parse parses raw ICS data into events, interpreting floating times in the
+// parser's timezone. Use ParseFeed to find out which events were skipped.
 func (p *Parser) Parse(data []byte) ([]Event, error) {
-	lines := strings.Split(string(data), "\n")
+	events, _, err := p.ParseFeed(Feed{TimeZone: p.timezone}, data)
+	return events, err
+}
+
+// ParseFeed parses raw ICS data from a feed into events. See ParseReader.
+func (p *Parser) ParseFeed(feed Feed, data []byte) ([]Event, []*ParseError, error) {
 	var events []Event
-	var currentEvent *Event
+	parseErrors, err := p.ParseReader(feed, bytes.NewReader(data), func(event Event) error {
+		events = append(events, event)
+		return nil
+	})
+	if err != nil {
+		return nil, parseErrors, err
+	}
+	return events, parseErrors, nil
+}
 
-	for i := 0; i < len(lines); i++ {
-		line := strings.TrimSpace(lines[i])
+// ParseReader parses the VEVENT and VFREEBUSY components of a feed, along
+// with VTODOs when enabled, and passes each event overlapping the parser's
+// window to emit. jCal and xCal feeds are converted to ICS first; their
+// format is taken from the feed or detected from the data. Floating times
+// are interpreted in the feed's timezone, falling back to the parser's.
+// Emitted times are expressed in the parser's timezone.
+//
+// Events are emitted as they are read and those outside the window dropped,
+// so memory use does not grow with the size of the feed. Recurring events
+// are held back until the end of the stream, when all of their overridden
+// instances are known. An error returned by emit stops parsing.
+//
+// Malformed content is returned as parse errors. In lenient mode the events
+// containing it are skipped; in strict mode the whole feed fails and events
+// already emitted should be discarded. Content that is valid but only partly
+// supported is returned as warnings, which neither mode fails on.
+func (p *Parser) ParseReader(feed Feed, r io.Reader, emit func(Event) error) ([]*ParseError, error) {
+	st := &parseState{
+		feedID:    feed.ID,
+		floating:  feed.TimeZone,
+		timezones: make(map[string]*time.Location),
+	}
+	if st.floating == nil {
+		st.floating = p.timezone
+	}
 
-		// Handle line continuations
-		for i+1 < len(lines) && strings.HasPrefix(lines[i+1], " ") {
-			line += " " + strings.TrimSpace(lines[i+1])
-			i++
+	r, err := toICS(feed.Format, r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Recurrence sets wait for the end of the stream; other events are
+	// emitted as soon as they are complete
+	var vevents []*vevent
+	collect := func(v *vevent) error {
+		if v.rrule != "" || len(v.rdates) > 0 || !v.RecurrenceID.IsZero() {
+			vevents = append(vevents, v)
+			return nil
+		}
+		if v.cancelled {
+			return nil
 		}
+		return p.emit(v.Event, emit)
+	}
 
-		switch {
-		case line == "BEGIN:VEVENT":
-			currentEvent = &Event{}
-		case line == "END:VEVENT":
+	var currentEvent *vevent // VEVENT, or VTODO when todos are included
+	var currentZone *vtimezone
+	var currentObservance *observance
+	var stack []string // open components, innermost last
+
+	lex := newLexer(r)
+	for {
+		prop, err := lex.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return st.errors, fmt.Errorf("failed to read feed: %w", err)
+		}
+
+		if prop.malformed {
+			st.report(prop, errors.New("malformed content line"))
 			if currentEvent != nil {
-				// Check SUMMARY for busy indication before adding event
-				// Because some ics feeds might be private and publish
-				// availability in summary field instead of title
-				if strings.Contains(strings.ToUpper(currentEvent.Title), "BUSY") {
-					currentEvent.Status = StatusBusy
+				currentEvent.invalid = true
+			}
+			continue
+		}
+
+		switch prop.name {
+		case "BEGIN":
+			component := strings.ToUpper(strings.TrimSpace(prop.value))
+			stack = append(stack, component)
+			switch {
+			case component == "VEVENT":
+				currentEvent = &vevent{line: prop.line}
+			case component == "VTODO" && p.includeTodos:
+				currentEvent = &vevent{line: prop.line, todo: true}
+			case component == "VTIMEZONE":
+				currentZone = &vtimezone{}
+			case (component == "STANDARD" || component == "DAYLIGHT") && currentZone != nil:
+				currentObservance = &observance{daylight: component == "DAYLIGHT"}
+				currentZone.observances = append(currentZone.observances, currentObservance)
+			}
+			continue
+		case "END":
+			component := strings.ToUpper(strings.TrimSpace(prop.value))
+			// END closes the innermost matching component, implicitly
+			// closing anything left open inside it; stray ENDs are ignored
+			i := len(stack) - 1
+			for i >= 0 && stack[i] != component {
+				i--
+			}
+			if i < 0 {
+				continue
+			}
+			stack = stack[:i]
+
+			switch component {
+			case "VEVENT", "VTODO":
+				if currentEvent != nil && currentEvent.todo == (component == "VTODO") {
+					if p.finish(st, currentEvent) {
+						if err := collect(currentEvent); err != nil {
+							return st.errors, err
+						}
+					}
+					currentEvent = nil
 				}
-				// logger.Debug("Event: ", currentEvent)
-				events = append(events, *currentEvent)
-				currentEvent = nil
+			case "VTIMEZONE":
+				if currentZone != nil {
+					st.addTimezone(prop, currentZone)
+				}
+				currentZone, currentObservance = nil, nil
+			case "STANDARD", "DAYLIGHT":
+				currentObservance = nil
 			}
-		case strings.HasPrefix(line, "DTSTART"):
-			if currentEvent != nil {
-				currentEvent.Start = p.parseDateTime(line)
+			continue
+		}
+
+		// Properties belong to the innermost open component only, so a
+		// VALARM's DESCRIPTION cannot overwrite its event's
+		var component string
+		if len(stack) > 0 {
+			component = stack[len(stack)-1]
+		}
+		switch {
+		case (component == "VEVENT" || component == "VTODO") && currentEvent != nil:
+			parse := st.parseEventProperty
+			if currentEvent.todo {
+				parse = st.parseTodoProperty
 			}
-		case strings.HasPrefix(line, "DTEND"):
-			if currentEvent != nil {
-				currentEvent.End = p.parseDateTime(line)
+			if err := parse(prop, currentEvent); err != nil {
+				st.report(prop, err)
+				currentEvent.invalid = true
 			}
-		case strings.HasPrefix(line, "SUMMARY"):
-			if currentEvent != nil {
-				currentEvent.Title = p.parseText(line)
+		case component == "VFREEBUSY" && prop.name == "FREEBUSY":
+			// Free/busy-only feeds publish periods without event details
+			blocks, err := st.parseFreeBusy(prop)
+			if err != nil {
+				st.report(prop, err)
+				continue
 			}
-		case strings.HasPrefix(line, "DESCRIPTION"):
-			if currentEvent != nil {
-				currentEvent.Description = p.parseText(line)
+			for _, block := range blocks {
+				if err := collect(block); err != nil {
+					return st.errors, err
+				}
 			}
-		case strings.HasPrefix(line, "LOCATION"):
-			if currentEvent != nil {
-				currentEvent.Location = p.parseText(line)
+		case component == "VTIMEZONE" && currentZone != nil:
+			if prop.name == "TZID" {
+				currentZone.tzid = prop.value
 			}
-		case strings.HasPrefix(line, "STATUS"):
-			// Outlook feed all entries are STATUS:CONFIRMED
-			if currentEvent != nil {
-				currentEvent.Status = p.parseStatus(line)
+		case (component == "STANDARD" || component == "DAYLIGHT") && currentObservance != nil:
+			currentObservance.parseProperty(prop)
+		}
+	}
+
+	if bad := malformed(st.errors); p.mode == ParseStrict && len(bad) > 0 {
+		return st.errors, fmt.Errorf("%d malformed entries, first: %w", len(bad), bad[0])
+	}
+
+	for _, event := range p.resolve(vevents) {
+		if err := p.emit(event, emit); err != nil {
+			return st.errors, err
+		}
+	}
+	return st.errors, nil
+}
+
+// emit localizes an event and passes it on if it overlaps the window
+func (p *Parser) emit(event Event, emit func(Event) error) error {
+	if !p.inWindow(event) {
+		return nil
+	}
+	return emit(p.localize(event))
+}
+
+// inWindow reports whether an event overlaps the parser's window. Without a
+// window every event is kept.
+func (p *Parser) inWindow(event Event) bool {
+	if !p.windowEnd.IsZero() && !event.Start.Before(p.windowEnd) {
+		return false
+	}
+	if event.End.After(event.Start) {
+		return event.End.After(p.windowStart)
+	}
+	return !event.Start.Before(p.windowStart)
+}
+
+// finish completes a parsed VEVENT or VTODO and reports whether it should be
+// kept. Cancelled and declined events are kept so they can suppress the
+// instances they override.
+func (p *Parser) finish(st *parseState, v *vevent) bool {
+	if v.invalid {
+		return false
+	}
+	if v.todo {
+		if !v.scheduleTodo() {
+			return false
+		}
+	} else if v.Start.IsZero() {
+		st.errors = append(st.errors, &ParseError{
+			FeedID:   st.feedID,
+			Line:     v.line,
+			Property: "VEVENT",
+			Raw:      "BEGIN:VEVENT",
+			Err:      errMissingStart,
+		})
+		return false
+	}
+	v.Status, v.cancelled = p.availability(v)
+	v.setDefaultEnd()
+	return true
+}
+
+// parseEventProperty handles a property of a VEVENT
+func (st *parseState) parseEventProperty(prop property, event *vevent) error {
+	var err error
+	switch prop.name {
+	case "UID":
+		event.UID = prop.text()
+	case "SEQUENCE":
+		event.Sequence, err = strconv.Atoi(strings.TrimSpace(prop.value))
+	case "RECURRENCE-ID":
+		event.RecurrenceID, _, err = st.parseDateTime(prop)
+	case "RRULE":
+		// Validated here so the error can point at the line; UNTIL is
+		// re-parsed in the DTSTART zone during expansion
+		event.rrule = strings.TrimSpace(prop.value)
+		_, err = ParseRecurrenceRule(event.rrule, time.UTC)
+		if errors.Is(err, ErrUnsupportedRule) {
+			// The event keeps its first instance rather than losing its
+			// busy time altogether
+			st.warn(prop, err)
+			err = nil
+		}
+	case "EXDATE":
+		var exdates []time.Time
+		exdates, err = st.parseDateTimeList(prop)
+		event.exdates = append(event.exdates, exdates...)
+	case "RDATE":
+		var rdates []period
+		rdates, err = st.parsePeriodList(prop)
+		event.rdates = append(event.rdates, rdates...)
+	case "DTSTART":
+		event.Start, event.AllDay, err = st.parseDateTime(prop)
+	case "DTEND":
+		// DTEND of a date-only event is exclusive: an event on
+		// March 10 ends at midnight starting March 11
+		event.End, _, err = st.parseDateTime(prop)
+	case "DURATION":
+		var d duration
+		if d, err = parseDuration(prop.value); err == nil {
+			event.duration = &d
+		}
+	case "SUMMARY":
+		event.Title = prop.text()
+	case "DESCRIPTION":
+		event.Description = prop.text()
+	case "LOCATION":
+		event.Location = prop.text()
+	case "STATUS":
+		event.status = strings.ToUpper(strings.TrimSpace(prop.value))
+	case "TRANSP":
+		event.transp = strings.ToUpper(strings.TrimSpace(prop.value))
+	case "X-MICROSOFT-CDO-BUSYSTATUS":
+		// Outlook marks every entry STATUS:CONFIRMED and publishes the
+		// actual "show as" value in these vendor properties
+		event.busyStatus = strings.ToUpper(strings.TrimSpace(prop.value))
+	case "X-MICROSOFT-CDO-INTENDEDSTATUS":
+		event.intendedStatus = strings.ToUpper(strings.TrimSpace(prop.value))
+	case "ATTENDEE":
+		if email := attendeeEmail(prop); email != "" {
+			if event.attendees == nil {
+				event.attendees = make(map[string]string)
 			}
+			event.attendees[email] = strings.ToUpper(prop.params["PARTSTAT"])
+		}
+	}
+	return err
+}
+
+// availability derives how an event affects the owner's availability the
+// way calendar clients do, and reports whether it should be dropped because
+// it was cancelled or declined
+func (p *Parser) availability(v *vevent) (Status, bool) {
+	if v.status == "CANCELLED" {
+		return StatusAvailable, true
+	}
+
+	partstat := ""
+	for email := range p.ownerEmails {
+		if ps, ok := v.attendees[email]; ok {
+			partstat = ps
+			break
+		}
+	}
+	if partstat == "DECLINED" {
+		return StatusAvailable, true
+	}
+
+	// Some ics feeds might be private and publish availability in the
+	// summary field instead of a status
+	if p.titleHeuristic && strings.Contains(strings.ToUpper(v.Title), "BUSY") {
+		return StatusBusy, false
+	}
+
+	// The owner's own busy status is preferred over the status the
+	// organizer intended attendees to have
+	for _, value := range []string{v.busyStatus, v.intendedStatus} {
+		if status, ok := parseMicrosoftBusyStatus(value); ok {
+			return status, false
 		}
 	}
 
-	return events, nil
+	if v.transp == "TRANSPARENT" {
+		return StatusAvailable, false
+	}
+	if partstat == "NEEDS-ACTION" || partstat == "TENTATIVE" {
+		return StatusTentative, false
+	}
+	return parseStatus(v.status), false
 }
 
-func (p *Parser) parseDateTime(line string) time.Time {
-	parts := strings.Split(line, ":")
-	if len(parts) != 2 {
-		return time.Time{}
+// parseMicrosoftBusyStatus maps an Outlook "show as" value onto
+// availability and reports whether the value was recognised
+func parseMicrosoftBusyStatus(value string) (Status, bool) {
+	switch value {
+	case "FREE", "WORKINGELSEWHERE":
+		return StatusAvailable, true
+	case "TENTATIVE":
+		return StatusTentative, true
+	case "BUSY":
+		return StatusBusy, true
+	case "OOF":
+		return StatusOutOfOffice, true
+	default:
+		return "", false
 	}
+}
 
-	// Handle different datetime formats
-	dt := parts[1]
-	formats := []string{
-		"20060102T150405Z", // UTC
-		"20060102T150405",  // Local
-		"YYYYMMDD",         // Date only
+// attendeeEmail returns the lower-cased address of an ATTENDEE, taken from
+// its mailto: value or EMAIL parameter
+func attendeeEmail(prop property) string {
+	value := strings.TrimSpace(prop.value)
+	if len(value) > len("mailto:") && strings.EqualFold(value[:len("mailto:")], "mailto:") {
+		return strings.ToLower(value[len("mailto:"):])
 	}
+	return strings.ToLower(prop.params["EMAIL"])
+}
 
-	for _, format := range formats {
-		if t, err := time.ParseInLocation(format, dt, p.timezone); err == nil {
+// localize expresses an event's times in the parser's timezone. All-day
+// events keep their calendar dates rather than their instants.
+func (p *Parser) localize(event Event) Event {
+	convert := func(t time.Time) time.Time {
+		if t.IsZero() {
 			return t
 		}
+		if event.AllDay {
+			y, m, d := t.Date()
+			return time.Date(y, m, d, 0, 0, 0, 0, p.timezone)
+		}
+		return t.In(p.timezone)
 	}
 
-	return time.Time{}
+	event.Start = convert(event.Start)
+	event.End = convert(event.End)
+	event.RecurrenceID = convert(event.RecurrenceID)
+	return event
 }
 
-func (p *Parser) parseText(line string) string {
-	parts := strings.SplitN(line, ":", 2)
-	if len(parts) != 2 {
-		return ""
+// addTimezone registers a VTIMEZONE completed by its END property.
+// Definitions are expected to precede the events that reference them.
+func (st *parseState) addTimezone(end property, zone *vtimezone) {
+	loc, err := zone.location()
+	if err != nil {
+		st.report(end, fmt.Errorf("invalid VTIMEZONE %s: %w", zone.tzid, err))
+		return
 	}
-	return strings.TrimSpace(parts[1])
+	st.timezones[zone.tzid] = loc
 }
 
-func (p *Parser) parseStatus(line string) Status {
-	status := strings.ToUpper(p.parseText(line))
-	// logger.Debug("status", status, line)
-	switch status {
+// zone resolves a TZID parameter: IANA and Windows names first, then the
+// feed's own VTIMEZONE definitions, then the feed's floating timezone
+func (st *parseState) zone(tzid string) *time.Location {
+	if tzid == "" {
+		return st.floating
+	}
+	if loc := LookupZone(tzid); loc != nil {
+		return loc
+	}
+	if loc, ok := st.timezones[tzid]; ok {
+		return loc
+	}
+	logger.Debug("unknown TZID %q, using %s", tzid, st.floating)
+	return st.floating
+}
+
+// parseDateTime parses a DATE or DATE-TIME property and reports whether the
+// value was date-only
+func (st *parseState) parseDateTime(prop property) (time.Time, bool, error) {
+	value := strings.TrimSpace(prop.value)
+	isDate := strings.EqualFold(prop.params["VALUE"], "DATE") || len(value) == len("20060102")
+	t, err := parseDateTimeValue(value, st.zone(prop.params["TZID"]))
+	return t, isDate, err
+}
+
+// parseDateTimeList parses a comma separated list of date-times as used by EXDATE
+func (st *parseState) parseDateTimeList(prop property) ([]time.Time, error) {
+	loc := st.zone(prop.params["TZID"])
+
+	var times []time.Time
+	for _, value := range strings.Split(prop.value, ",") {
+		t, err := parseDateTimeValue(value, loc)
+		if err != nil {
+			return nil, err
+		}
+		times = append(times, t)
+	}
+	return times, nil
+}
+
+// parsePeriodList parses RDATE values, which are either date-times or
+// periods given as "start/end" or "start/duration"
+func (st *parseState) parsePeriodList(prop property) ([]period, error) {
+	loc := st.zone(prop.params["TZID"])
+
+	var periods []period
+	for _, value := range strings.Split(prop.value, ",") {
+		startValue, endValue, isPeriod := strings.Cut(value, "/")
+		start, err := parseDateTimeValue(startValue, loc)
+		if err != nil {
+			return nil, err
+		}
+		pd := period{start: start}
+		if isPeriod {
+			if d, err := parseDuration(endValue); err == nil {
+				pd.end = d.addTo(pd.start)
+			} else if pd.end, err = parseDateTimeValue(endValue, loc); err != nil {
+				return nil, err
+			}
+		}
+		periods = append(periods, pd)
+	}
+	return periods, nil
+}
+
+func parseDateTimeValue(dt string, loc *time.Location) (time.Time, error) {
+	dt = strings.TrimSpace(dt)
+
+	// UTC times ignore any TZID
+	if t, err := time.Parse("20060102T150405Z", dt); err == nil {
+		return t, nil
+	}
+
+	// Handle different datetime formats
+	formats := []string{
+		"20060102T150405", // Local
+		"20060102",        // Date only
+	}
+
+	for _, format := range formats {
+		if t, err := time.ParseInLocation(format, dt, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date-time %q", dt)
+}
+
+// parseStatus maps a STATUS value onto availability. Opaque events block
+// time unless they are tentative; FREE is not an RFC 5545 status but some
+// feeds publish it.
+func parseStatus(status string) Status {
+	switch strings.ToUpper(strings.TrimSpace(status)) {
 	case "TENTATIVE":
 		return StatusTentative
-	case "BUSY":
-		return StatusBusy
-	case "CONFIRMED":
+	case "FREE":
 		return StatusAvailable
 	default:
-		return StatusAvailable
+		return StatusBusy
 	}
 }
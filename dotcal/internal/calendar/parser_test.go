@@ -1,6 +1,8 @@
 package calendar
 
 import (
+	"errors"
+	"strings"
 	"testing"
 	"time"
 )
@@ -68,8 +70,8 @@ END:VCALENDAR`
 			t.Errorf("Expected location 'Conference Room', got '%s'", event.Location)
 		}
 
-		if event.Status != StatusAvailable {
-			t.Errorf("Expected status Available, got %v", event.Status)
+		if event.Status != StatusBusy {
+			t.Errorf("Expected status Busy, got %v", event.Status)
 		}
 	})
 
@@ -78,8 +80,8 @@ END:VCALENDAR`
 BEGIN:VEVENT
 DTSTART:20250215T100000Z
 DESCRIPTION:This is a very long description that spans
- multiple lines in the ICS file but should be treated
- as a single continuous string
+  multiple lines in the ICS file but should be treated
+  as a single continuous string
 END:VEVENT
 END:VCALENDAR`
 
@@ -134,9 +136,9 @@ END:VCALENDAR`
 		}{
 			{"TENTATIVE", StatusTentative},
 			{"BUSY", StatusBusy},
-			{"CONFIRMED", StatusAvailable},
+			{"CONFIRMED", StatusBusy},
 			{"FREE", StatusAvailable},
-			{"", StatusAvailable},
+			{"", StatusBusy},
 		}
 
 		for _, tc := range tests {
@@ -169,7 +171,9 @@ STATUS:CONFIRMED
 END:VEVENT
 END:VCALENDAR`
 
-		events, err := parser.Parse([]byte(input))
+		heuristic := NewParser(time.UTC)
+		heuristic.SetTitleHeuristic(true)
+		events, err := heuristic.Parse([]byte(input))
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
@@ -181,6 +185,24 @@ END:VCALENDAR`
 		}
 	})
 
+	t.Run("busy in summary is opt-in", func(t *testing.T) {
+		input := `BEGIN:VCALENDAR
+BEGIN:VEVENT
+DTSTART:20250215T100000Z
+SUMMARY:Not busy after all
+TRANSP:TRANSPARENT
+END:VEVENT
+END:VCALENDAR`
+
+		events, err := parser.Parse([]byte(input))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(events) != 1 || events[0].Status != StatusAvailable {
+			t.Errorf("Expected available event, got %v", events)
+		}
+	})
+
 	t.Run("different datetime formats", func(t *testing.T) {
 		tests := []struct {
 			dtstart  string
@@ -209,4 +231,455 @@ END:VCALENDAR`
 			}
 		}
 	})
+	t.Run("recurring event is expanded within window", func(t *testing.T) {
+		input := `BEGIN:VCALENDAR
+BEGIN:VEVENT
+DTSTART:20250303T150000Z
+DTEND:20250303T153000Z
+RRULE:FREQ=WEEKLY;BYDAY=MO
+SUMMARY:Standup
+END:VEVENT
+END:VCALENDAR`
+
+		windowed := NewParser(time.UTC)
+		windowed.SetWindow(
+			time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+			time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC),
+		)
+
+		events, err := windowed.Parse([]byte(input))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(events) != 3 {
+			t.Fatalf("Expected 3 instances, got %d", len(events))
+		}
+		for i, event := range events {
+			expectedStart := time.Date(2025, 3, 10+7*i, 15, 0, 0, 0, time.UTC)
+			if !event.Start.Equal(expectedStart) {
+				t.Errorf("Instance %d: expected start %v, got %v", i, expectedStart, event.Start)
+			}
+			if event.End.Sub(event.Start) != 30*time.Minute {
+				t.Errorf("Instance %d: expected 30 minute duration, got %v", i, event.End.Sub(event.Start))
+			}
+			if event.Title != "Standup" {
+				t.Errorf("Instance %d: expected title 'Standup', got '%s'", i, event.Title)
+			}
+		}
+	})
+
+	t.Run("invalid recurrence rule is reported", func(t *testing.T) {
+		input := `BEGIN:VCALENDAR
+BEGIN:VEVENT
+DTSTART:20250303T150000Z
+DTEND:20250303T153000Z
+RRULE:FREQ=SOMETIMES
+END:VEVENT
+END:VCALENDAR`
+
+		events, parseErrors, err := parser.ParseFeed(Feed{}, []byte(input))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(events) != 0 {
+			t.Errorf("Expected event to be skipped, got %d", len(events))
+		}
+		if len(parseErrors) != 1 || parseErrors[0].Property != "RRULE" || parseErrors[0].Line != 5 {
+			t.Errorf("Expected RRULE error on line 5, got %v", parseErrors)
+		}
+	})
+
+	t.Run("unsupported recurrence rule keeps first instance", func(t *testing.T) {
+		input := `BEGIN:VCALENDAR
+BEGIN:VEVENT
+DTSTART:20250303T150000Z
+DTEND:20250303T153000Z
+RRULE:FREQ=DAILY;BYHOUR=9,15
+SUMMARY:Check-in
+END:VEVENT
+END:VCALENDAR`
+
+		events, parseErrors, err := parser.ParseFeed(Feed{}, []byte(input))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(events) != 1 || !events[0].Start.Equal(time.Date(2025, 3, 3, 15, 0, 0, 0, time.UTC)) {
+			t.Errorf("Expected the DTSTART instance, got %v", events)
+		}
+		if len(parseErrors) != 1 || !errors.Is(parseErrors[0], ErrUnsupportedRule) || !parseErrors[0].Warning {
+			t.Errorf("Expected an unsupported RRULE warning, got %v", parseErrors)
+		}
+	})
+
+	t.Run("unsupported recurrence rule passes strict mode", func(t *testing.T) {
+		input := `BEGIN:VCALENDAR
+BEGIN:VEVENT
+DTSTART:20250303T150000Z
+DTEND:20250303T153000Z
+RRULE:FREQ=DAILY;BYHOUR=9,15
+END:VEVENT
+END:VCALENDAR`
+
+		strict := NewParser(time.UTC)
+		strict.SetMode(ParseStrict)
+		events, parseErrors, err := strict.ParseFeed(Feed{}, []byte(input))
+		if err != nil {
+			t.Fatalf("Expected strict mode to accept a valid rule, got %v", err)
+		}
+		if len(events) != 1 || len(parseErrors) != 1 {
+			t.Errorf("Expected 1 event and 1 warning, got %d and %v", len(events), parseErrors)
+		}
+	})
+}
+
+func TestParseAllDay(t *testing.T) {
+	parser := NewParser(time.UTC)
+
+	input := `BEGIN:VCALENDAR
+BEGIN:VEVENT
+DTSTART;VALUE=DATE:20250310
+DTEND;VALUE=DATE:20250313
+SUMMARY:Offsite
+END:VEVENT
+END:VCALENDAR`
+
+	events, err := parser.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(events))
+	}
+
+	event := events[0]
+	if !event.AllDay {
+		t.Error("Expected event to be all-day")
+	}
+	if !event.Start.Equal(time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected start at midnight March 10, got %v", event.Start)
+	}
+	if !event.End.Equal(time.Date(2025, 3, 13, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected exclusive end at midnight March 13, got %v", event.End)
+	}
+
+	t.Run("dates are kept in the parser timezone", func(t *testing.T) {
+		boise, err := time.LoadLocation("America/Boise")
+		if err != nil {
+			t.Skip("timezone data unavailable")
+		}
+		events, _, err := NewParser(boise).ParseFeed(Feed{TimeZone: time.UTC}, []byte(input))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if events[0].Start.Day() != 10 || events[0].Start.Hour() != 0 {
+			t.Errorf("Expected midnight March 10 in Boise, got %v", events[0].Start)
+		}
+	})
+}
+
+func TestParseDurationEnd(t *testing.T) {
+	parser := NewParser(time.UTC)
+
+	t.Run("duration instead of dtend", func(t *testing.T) {
+		input := `BEGIN:VCALENDAR
+BEGIN:VEVENT
+DTSTART:20250310T090000Z
+DURATION:PT45M
+END:VEVENT
+END:VCALENDAR`
+
+		events, err := parser.Parse([]byte(input))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(events) != 1 {
+			t.Fatalf("Expected 1 event, got %d", len(events))
+		}
+		expected := time.Date(2025, 3, 10, 9, 45, 0, 0, time.UTC)
+		if !events[0].End.Equal(expected) {
+			t.Errorf("Expected end %v, got %v", expected, events[0].End)
+		}
+	})
+
+	t.Run("negative duration is rejected", func(t *testing.T) {
+		input := `BEGIN:VCALENDAR
+BEGIN:VEVENT
+DTSTART:20250310T090000Z
+DURATION:-PT45M
+END:VEVENT
+END:VCALENDAR`
+
+		events, parseErrors, err := parser.ParseFeed(Feed{}, []byte(input))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(events) != 0 {
+			t.Errorf("Expected event to be skipped, got %v", events)
+		}
+		if len(parseErrors) != 1 || parseErrors[0].Property != "DURATION" {
+			t.Errorf("Expected DURATION error, got %v", parseErrors)
+		}
+	})
+
+	t.Run("date-only start defaults to one day", func(t *testing.T) {
+		input := `BEGIN:VCALENDAR
+BEGIN:VEVENT
+DTSTART;VALUE=DATE:20250310
+END:VEVENT
+END:VCALENDAR`
+
+		events, err := parser.Parse([]byte(input))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		expected := time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC)
+		if len(events) != 1 || !events[0].End.Equal(expected) {
+			t.Errorf("Expected end %v, got %v", expected, events)
+		}
+	})
+
+	t.Run("recurring duration keeps wall clock length", func(t *testing.T) {
+		nyc, err := time.LoadLocation("America/New_York")
+		if err != nil {
+			t.Skip("timezone data unavailable")
+		}
+		input := `BEGIN:VCALENDAR
+BEGIN:VEVENT
+DTSTART;TZID=America/New_York:20250307T090000
+DURATION:P1D
+RRULE:FREQ=DAILY;COUNT=3
+END:VEVENT
+END:VCALENDAR`
+
+		p := NewParser(nyc)
+		p.SetWindow(time.Date(2025, 3, 1, 0, 0, 0, 0, nyc), time.Date(2025, 3, 15, 0, 0, 0, 0, nyc))
+		events, err := p.Parse([]byte(input))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(events) != 3 {
+			t.Fatalf("Expected 3 instances, got %d", len(events))
+		}
+		expected := time.Date(2025, 3, 9, 9, 0, 0, 0, nyc)
+		if !events[1].End.Equal(expected) {
+			t.Errorf("Expected end %v, got %v", expected, events[1].End)
+		}
+	})
+}
+
+func TestParseAvailability(t *testing.T) {
+	parser := NewParser(time.UTC)
+	parser.SetOwnerEmails([]string{"Me@Example.com"})
+	parser.SetWindow(
+		time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC),
+	)
+
+	tests := []struct {
+		name       string
+		properties string
+		expected   Status
+		dropped    bool
+	}{
+		{"opaque by default", "", StatusBusy, false},
+		{"transparent is free", "TRANSP:TRANSPARENT", StatusAvailable, false},
+		{"tentative", "STATUS:TENTATIVE", StatusTentative, false},
+		{"cancelled is dropped", "STATUS:CANCELLED", "", true},
+		{"owner declined", "ATTENDEE;PARTSTAT=DECLINED:mailto:me@example.com", "", true},
+		{"owner has not responded", "ATTENDEE;PARTSTAT=NEEDS-ACTION:MAILTO:me@example.com", StatusTentative, false},
+		{"owner accepted", "ATTENDEE;PARTSTAT=ACCEPTED:mailto:me@example.com", StatusBusy, false},
+		{"other attendee declined", "ATTENDEE;PARTSTAT=DECLINED:mailto:you@example.com", StatusBusy, false},
+		{"owner matched by email parameter", "ATTENDEE;EMAIL=me@example.com;PARTSTAT=DECLINED:urn:uuid:1234", "", true},
+		{"delegated attendee", `ATTENDEE;DELEGATED-FROM="mailto:a@x.com","mailto:b@x.com";PARTSTAT=ACCEPTED:mailto:me@example.com`, StatusBusy, false},
+		{"delegated owner declined", `ATTENDEE;DELEGATED-FROM="mailto:a@x.com","mailto:b@x.com";PARTSTAT=DECLINED:mailto:me@example.com`, "", true},
+		{"outlook free", "STATUS:CONFIRMED\nX-MICROSOFT-CDO-BUSYSTATUS:FREE", StatusAvailable, false},
+		{"outlook tentative", "X-MICROSOFT-CDO-BUSYSTATUS:TENTATIVE", StatusTentative, false},
+		{"outlook out of office", "X-MICROSOFT-CDO-BUSYSTATUS:OOF", StatusOutOfOffice, false},
+		{"outlook working elsewhere", "X-MICROSOFT-CDO-BUSYSTATUS:WORKINGELSEWHERE", StatusAvailable, false},
+		{"outlook intended status", "X-MICROSOFT-CDO-INTENDEDSTATUS:OOF", StatusOutOfOffice, false},
+		{"outlook busy status wins", "X-MICROSOFT-CDO-INTENDEDSTATUS:FREE\nX-MICROSOFT-CDO-BUSYSTATUS:BUSY", StatusBusy, false},
+		{"outlook busy overrides transparency", "TRANSP:TRANSPARENT\nX-MICROSOFT-CDO-BUSYSTATUS:BUSY", StatusBusy, false},
+		{"unknown outlook status", "X-MICROSOFT-CDO-BUSYSTATUS:SOMETHING", StatusBusy, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			input := "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:20250310T090000Z\nDTEND:20250310T100000Z\n" +
+				tc.properties + "\nEND:VEVENT\nEND:VCALENDAR"
+
+			events, err := parser.Parse([]byte(input))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if tc.dropped {
+				if len(events) != 0 {
+					t.Errorf("Expected event to be dropped, got %v", events)
+				}
+				return
+			}
+			if len(events) != 1 {
+				t.Fatalf("Expected 1 event, got %d", len(events))
+			}
+			if events[0].Status != tc.expected {
+				t.Errorf("Expected status %v, got %v", tc.expected, events[0].Status)
+			}
+		})
+	}
+
+	t.Run("cancelled override suppresses instance", func(t *testing.T) {
+		input := `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:weekly@example.com
+DTSTART:20250303T090000Z
+DTEND:20250303T100000Z
+RRULE:FREQ=WEEKLY;COUNT=3
+END:VEVENT
+BEGIN:VEVENT
+UID:weekly@example.com
+RECURRENCE-ID:20250310T090000Z
+DTSTART:20250310T090000Z
+DTEND:20250310T100000Z
+STATUS:CANCELLED
+END:VEVENT
+END:VCALENDAR`
+
+		events, err := parser.Parse([]byte(input))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(events) != 2 {
+			t.Fatalf("Expected 2 instances, got %d", len(events))
+		}
+		for _, event := range events {
+			if event.Start.Day() == 10 {
+				t.Errorf("Expected cancelled instance to be removed, got %v", event.Start)
+			}
+		}
+	})
+}
+
+func TestParseNestedComponents(t *testing.T) {
+	input := `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:review@example.com
+DTSTART:20250310T090000Z
+DTEND:20250310T100000Z
+SUMMARY:Design review
+DESCRIPTION:Walk through the mockups
+BEGIN:VALARM
+ACTION:DISPLAY
+TRIGGER:-PT15M
+DESCRIPTION:Reminder
+SUMMARY:Alarm summary
+END:VALARM
+LOCATION:Room 2
+END:VEVENT
+BEGIN:VTODO
+UID:todo@example.com
+DTSTART:20250311T090000Z
+DUE:20250311T120000Z
+SUMMARY:Write report
+END:VTODO
+BEGIN:VJOURNAL
+DTSTART:20250312T090000Z
+SUMMARY:Journal entry
+END:VJOURNAL
+END:VCALENDAR`
+
+	events, err := NewParser(time.UTC).Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("Expected only the VEVENT, got %d events: %v", len(events), events)
+	}
+
+	event := events[0]
+	if event.Title != "Design review" {
+		t.Errorf("Expected title 'Design review', got '%s'", event.Title)
+	}
+	if event.Description != "Walk through the mockups" {
+		t.Errorf("Expected VALARM to leave description alone, got '%s'", event.Description)
+	}
+	if event.Location != "Room 2" {
+		t.Errorf("Expected properties after VALARM to apply, got location '%s'", event.Location)
+	}
+
+	t.Run("todos can be included", func(t *testing.T) {
+		parser := NewParser(time.UTC)
+		parser.SetIncludeTodos(true)
+		events, err := parser.Parse([]byte(input))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(events) != 2 {
+			t.Fatalf("Expected event and to-do, got %d events", len(events))
+		}
+		if events[1].Title != "Write report" || events[1].Status != StatusBusy {
+			t.Errorf("Expected busy to-do, got %+v", events[1])
+		}
+	})
+}
+
+func TestParseReader(t *testing.T) {
+	input := `BEGIN:VCALENDAR
+BEGIN:VEVENT
+SUMMARY:Before
+DTSTART:20250303T090000Z
+DTEND:20250303T100000Z
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:Spans window start
+DTSTART:20250309T220000Z
+DTEND:20250310T020000Z
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:Weekly
+UID:weekly@example.com
+DTSTART:20250303T120000Z
+DTEND:20250303T130000Z
+RRULE:FREQ=WEEKLY
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:After
+DTSTART:20250317T090000Z
+DTEND:20250317T100000Z
+END:VEVENT
+END:VCALENDAR`
+
+	parser := NewParser(time.UTC)
+	parser.SetWindow(
+		time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 3, 17, 0, 0, 0, 0, time.UTC),
+	)
+
+	t.Run("events outside the window are dropped", func(t *testing.T) {
+		var titles []string
+		_, err := parser.ParseReader(Feed{}, strings.NewReader(input), func(event Event) error {
+			titles = append(titles, event.Title)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		expected := []string{"Spans window start", "Weekly"}
+		if strings.Join(titles, ",") != strings.Join(expected, ",") {
+			t.Errorf("Expected %v, got %v", expected, titles)
+		}
+	})
+
+	t.Run("emit error stops parsing", func(t *testing.T) {
+		stop := errors.New("stop")
+		calls := 0
+		_, err := parser.ParseReader(Feed{}, strings.NewReader(input), func(event Event) error {
+			calls++
+			return stop
+		})
+		if !errors.Is(err, stop) {
+			t.Errorf("Expected emit error to be returned, got %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("Expected parsing to stop after the first event, got %d calls", calls)
+		}
+	})
 }
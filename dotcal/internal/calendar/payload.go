@@ -0,0 +1,161 @@
+package calendar
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// defaultMaxFeedSize bounds how much of a feed is read, after decompression,
+// unless the fetcher sets its own limit
+const defaultMaxFeedSize = 50 << 20
+
+// acceptEncoding is sent with feed requests. Setting it ourselves stops the
+// HTTP client from decompressing gzip only, so bodies are decoded here.
+const acceptEncoding = "gzip, deflate"
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+
+	// errHTMLPage is returned for feeds that serve a web page, usually a
+	// sign-in page, instead of calendar data
+	errHTMLPage = errors.New("feed returned an HTML page instead of a calendar")
+)
+
+// SetMaxFeedSize limits how many bytes of a feed are read after
+// decompression. Larger feeds fail instead of exhausting memory. Zero means
+// no limit.
+func (f *Fetcher) SetMaxFeedSize(size int64) {
+	f.maxFeedSize = size
+}
+
+// readBody decompresses a body according to its content encoding, or its
+// content when it has none, and limits it to the maximum feed size
+func (f *Fetcher) readBody(encoding string, body io.ReadCloser) (io.ReadCloser, error) {
+	br := bufio.NewReader(body)
+	var r io.Reader = br
+	var err error
+
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", "identity":
+		// Exported calendars are often gzipped files served as they are
+		if head, _ := br.Peek(len(gzipMagic)); bytes.Equal(head, gzipMagic) {
+			r, err = gzip.NewReader(br)
+		}
+	case "gzip", "x-gzip":
+		r, err = gzip.NewReader(br)
+	case "deflate":
+		// Deflate should be zlib wrapped, but some servers send it raw
+		if head, _ := br.Peek(2); len(head) == 2 && head[0]&0x0f == 8 && (int(head[0])<<8|int(head[1]))%31 == 0 {
+			r, err = zlib.NewReader(br)
+		} else {
+			r = flate.NewReader(br)
+		}
+	default:
+		err = fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+	if err != nil {
+		body.Close()
+		return nil, fmt.Errorf("failed to decompress feed: %w", err)
+	}
+
+	if f.maxFeedSize > 0 {
+		r = &limitedReader{r: r, limit: f.maxFeedSize, remaining: f.maxFeedSize}
+	}
+	return readCloser{Reader: r, Closer: body}, nil
+}
+
+// readCloser reads decoded data and closes the body it came from
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// limitedReader fails once more than limit bytes have been read, unlike
+// io.LimitReader, which silently truncates
+type limitedReader struct {
+	r         io.Reader
+	limit     int64
+	remaining int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	// Read one byte past the limit to tell a feed of exactly the limit apart
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	if int64(n) > l.remaining {
+		return int(l.remaining), fmt.Errorf("feed is larger than the limit of %d bytes", l.limit)
+	}
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// checkPayload makes sure data looks like a calendar before it replaces a
+// feed's events. A sign-in page served with 200 OK would otherwise parse to
+// no events and free all of the feed's busy time.
+func checkPayload(r *bufio.Reader) error {
+	head, err := r.Peek(512)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return fmt.Errorf("failed to read feed: %w", err)
+	}
+	head = bytes.TrimPrefix(head, utf8BOM)
+	head = bytes.TrimLeft(head, " \t\r\n")
+	if len(head) == 0 {
+		return fmt.Errorf("feed is empty")
+	}
+
+	// Calendars are recognized first, as their descriptions may hold HTML
+	lower := bytes.ToLower(head)
+	switch {
+	case bytes.HasPrefix(lower, []byte("begin:vcalendar")):
+		return nil
+	case head[0] == '[', bytes.HasPrefix(lower, []byte("<?xml")), isXCalRoot(lower):
+		// jCal and xCal are checked by their converters
+		return nil
+	case head[0] == '<':
+		// Any other markup is a web page
+		return errHTMLPage
+	}
+
+	line, _, _ := bytes.Cut(head, []byte("\n"))
+	if len(line) > 40 {
+		line = line[:40]
+	}
+	return fmt.Errorf("feed is not a calendar, it starts with %q", bytes.TrimSpace(line))
+}
+
+// isXCalRoot reports whether the lowercased data starts with an xCal
+// icalendar element, which may carry a namespace prefix
+func isXCalRoot(lower []byte) bool {
+	if len(lower) == 0 || lower[0] != '<' {
+		return false
+	}
+	name := lower[1:]
+	if i := bytes.IndexAny(name, " \t\r\n/>"); i >= 0 {
+		name = name[:i]
+	}
+	return bytes.HasSuffix(name, []byte("icalendar")) &&
+		(len(name) == len("icalendar") || name[len(name)-len("icalendar")-1] == ':')
+}
+
+// payload returns the response's data once it has been checked to be a
+// calendar
+func (r *feedResponse) payload() (io.Reader, error) {
+	br := bufio.NewReader(r.body)
+	err := checkPayload(br)
+	if errors.Is(err, errHTMLPage) && r.redirect != "" {
+		return nil, fmt.Errorf("%w after a redirect to %s, check the feed URL and credentials", err, r.redirect)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return br, nil
+}
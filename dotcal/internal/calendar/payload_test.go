@@ -0,0 +1,219 @@
+package calendar
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckPayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{name: "ics", data: "\xEF\xBB\xBF\r\nbegin:VCALENDAR\r\n"},
+		{name: "jcal", data: `["vcalendar", [], []]`},
+		{name: "xcal", data: `<?xml version="1.0"?><icalendar/>`},
+		{name: "xcal without declaration", data: `<xcal:icalendar xmlns:xcal="urn:ietf:params:xml:ns:icalendar-2.0">`},
+		{name: "ics with html description", data: "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nX-ALT-DESC;FMTTYPE=text/html:<html><head></head><body><header>Agenda</header></body></html>\r\n"},
+		{name: "html", data: "<!DOCTYPE html>\n<html><head><title>Sign in</title>", wantErr: "HTML page"},
+		{name: "html fragment", data: "<div>\n<body>Sign in</body>", wantErr: "HTML page"},
+		{name: "empty", data: " \r\n", wantErr: "empty"},
+		{name: "other text", data: "Service unavailable\nTry again later", wantErr: `starts with "Service unavailable"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkPayload(bufio.NewReader(strings.NewReader(tt.data)))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestReadBody(t *testing.T) {
+	data := sourceEvent("Compressed")
+
+	var gzipped, zlibbed, deflated bytes.Buffer
+	gw := gzip.NewWriter(&gzipped)
+	gw.Write([]byte(data))
+	gw.Close()
+	zw := zlib.NewWriter(&zlibbed)
+	zw.Write([]byte(data))
+	zw.Close()
+	fw, _ := flate.NewWriter(&deflated, flate.DefaultCompression)
+	fw.Write([]byte(data))
+	fw.Close()
+
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+	}{
+		{name: "identity", encoding: "", body: []byte(data)},
+		{name: "gzip", encoding: "gzip", body: gzipped.Bytes()},
+		{name: "gzipped file", encoding: "", body: gzipped.Bytes()},
+		{name: "zlib deflate", encoding: "deflate", body: zlibbed.Bytes()},
+		{name: "raw deflate", encoding: "deflate", body: deflated.Bytes()},
+	}
+
+	fetcher := newTestFetcher()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := fetcher.readBody(tt.encoding, io.NopCloser(bytes.NewReader(tt.body)))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			got, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(got) != data {
+				t.Errorf("Expected %q, got %q", data, got)
+			}
+		})
+	}
+
+	t.Run("unsupported encoding", func(t *testing.T) {
+		if _, err := fetcher.readBody("br", io.NopCloser(strings.NewReader(data))); err == nil {
+			t.Error("Expected error for an unsupported encoding")
+		}
+	})
+
+	t.Run("size limit", func(t *testing.T) {
+		fetcher := newTestFetcher()
+		fetcher.SetMaxFeedSize(int64(len(data)))
+
+		body, _ := fetcher.readBody("", io.NopCloser(strings.NewReader(data)))
+		if _, err := io.ReadAll(body); err != nil {
+			t.Errorf("Expected a feed of exactly the limit to be read, got %v", err)
+		}
+
+		fetcher.SetMaxFeedSize(int64(len(data)) - 1)
+		body, _ = fetcher.readBody("gzip", io.NopCloser(bytes.NewReader(gzipped.Bytes())))
+		if _, err := io.ReadAll(body); err == nil || !strings.Contains(err.Error(), "limit") {
+			t.Errorf("Expected the decompressed size to be limited, got %v", err)
+		}
+	})
+}
+
+func TestFetchPayloadChecks(t *testing.T) {
+	parser := NewParser(time.UTC)
+	loginPage := "<!DOCTYPE html><html><head><title>Sign in</title></head></html>"
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/feed.ics", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/login?token=secret", http.StatusFound)
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(loginPage))
+	})
+	mux.HandleFunc("/private.ics", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	mux.HandleFunc("/gzip.ics", func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			t.Errorf("Expected gzip to be accepted, got %q", r.Header.Get("Accept-Encoding"))
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gw := gzip.NewWriter(w)
+		gw.Write([]byte(sourceEvent("Compressed")))
+		gw.Close()
+	})
+	mux.HandleFunc("/huge.ics", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(sourceEvent(strings.Repeat("x", 4096))))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	t.Run("auth redirect", func(t *testing.T) {
+		fetcher := newTestFetcher()
+		fetcher.SetCacheDir(t.TempDir())
+
+		_, err := fetcher.FetchEvents(Feed{Source: server.URL + "/feed.ics", IsURL: true}, parser)
+		if err == nil {
+			t.Fatal("Expected error for a sign-in page")
+		}
+		if !strings.Contains(err.Error(), "HTML page") || !strings.Contains(err.Error(), "/login") {
+			t.Errorf("Expected the error to name the redirect, got %v", err)
+		}
+		if strings.Contains(err.Error(), "secret") {
+			t.Errorf("Expected the redirect URL to be redacted, got %v", err)
+		}
+		if fetcher.cache.load(server.URL+"/feed.ics") != nil {
+			t.Error("Expected the sign-in page not to be cached")
+		}
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		_, err := newTestFetcher().FetchEvents(Feed{Source: server.URL + "/private.ics", IsURL: true}, parser)
+		if err == nil || !strings.Contains(err.Error(), "authentication") {
+			t.Errorf("Expected an authentication error, got %v", err)
+		}
+	})
+
+	t.Run("gzip response", func(t *testing.T) {
+		result, err := newTestFetcher().FetchEvents(Feed{Source: server.URL + "/gzip.ics", IsURL: true}, parser)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(result.Events) != 1 || result.Events[0].Title != "Compressed" {
+			t.Errorf("Expected the compressed event, got %v", result.Events)
+		}
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		fetcher := newTestFetcher()
+		fetcher.SetMaxFeedSize(1024)
+		if _, err := fetcher.Fetch(Feed{Source: server.URL + "/huge.ics", IsURL: true}); err == nil {
+			t.Error("Expected error for a feed over the size limit")
+		}
+	})
+
+	t.Run("html description", func(t *testing.T) {
+		data := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VEVENT\r\nUID:1\r\n" +
+			"DTSTART:20250210T100000Z\r\nDTEND:20250210T110000Z\r\nSUMMARY:Review\r\n" +
+			"X-ALT-DESC;FMTTYPE=text/html:<html><body><header>Agenda</header></body></html>\r\n" +
+			"END:VEVENT\r\nEND:VCALENDAR\r\n"
+		path := filepath.Join(t.TempDir(), "outlook.ics")
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		result, err := newTestFetcher().FetchEvents(Feed{Source: path}, parser)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(result.Events) != 1 {
+			t.Errorf("Expected 1 event, got %d", len(result.Events))
+		}
+	})
+
+	t.Run("html file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "saved.ics")
+		if err := os.WriteFile(path, []byte(loginPage), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		if _, err := newTestFetcher().FetchEvents(Feed{Source: path}, parser); err == nil {
+			t.Error("Expected error for an HTML file")
+		}
+	})
+}
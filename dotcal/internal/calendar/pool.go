@@ -0,0 +1,112 @@
+package calendar
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+const (
+	// defaultConcurrency is how many feeds are fetched at once
+	defaultConcurrency = 4
+	// defaultHostConcurrency is how many of those may share a host
+	defaultHostConcurrency = 2
+)
+
+// SetConcurrency sets how many feeds FetchAll fetches at once
+func (f *Fetcher) SetConcurrency(n int) {
+	f.concurrency = n
+}
+
+// SetHostConcurrency limits how many feeds FetchAll fetches from the same
+// host at once. Zero means no per-host limit.
+func (f *Fetcher) SetHostConcurrency(n int) {
+	f.hostConcurrency = n
+}
+
+// FetchAll fetches and parses feeds concurrently. Results are in the order
+// of feeds; a feed that could not be fetched has its error in Err and no
+// events.
+func (f *Fetcher) FetchAll(ctx context.Context, feeds []Feed, parser *Parser) []FeedResult {
+	results := make([]FeedResult, len(feeds))
+	workers := make(chan struct{}, max(f.concurrency, 1))
+	hosts := &hostLimiter{limit: f.hostConcurrency, slots: make(map[string]chan struct{})}
+
+	var wg sync.WaitGroup
+	for i, feed := range feeds {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Wait for the host first so a busy host does not hold workers
+			// other hosts could use
+			release, err := hosts.acquire(ctx, feedHost(feed))
+			if err != nil {
+				results[i] = FeedResult{Feed: feed, Err: err}
+				return
+			}
+			defer release()
+
+			select {
+			case workers <- struct{}{}:
+				defer func() { <-workers }()
+			case <-ctx.Done():
+				results[i] = FeedResult{Feed: feed, Err: ctx.Err()}
+				return
+			}
+
+			result, err := f.FetchEventsContext(ctx, feed, parser)
+			if err != nil {
+				results[i] = FeedResult{Feed: feed, Err: err}
+				return
+			}
+			results[i] = *result
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// feedHost returns the lower-cased host of a URL feed, or "" for files
+func feedHost(feed Feed) string {
+	if !feed.IsURL {
+		return ""
+	}
+	u, err := url.Parse(feed.Source)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// hostLimiter bounds the number of concurrent fetches per host
+type hostLimiter struct {
+	limit int // 0 for no limit
+
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+// acquire waits for a slot for host and returns the function releasing it
+func (h *hostLimiter) acquire(ctx context.Context, host string) (func(), error) {
+	if h.limit <= 0 || host == "" {
+		return func() {}, nil
+	}
+
+	h.mu.Lock()
+	slots, ok := h.slots[host]
+	if !ok {
+		slots = make(chan struct{}, h.limit)
+		h.slots[host] = slots
+	}
+	h.mu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
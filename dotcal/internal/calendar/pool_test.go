@@ -0,0 +1,137 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// concurrencyServer serves a one-event feed named after the request path,
+// recording the most requests it handled at once
+type concurrencyServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+}
+
+func newConcurrencyServer(t *testing.T) *concurrencyServer {
+	s := &concurrencyServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.inFlight++
+		s.peak = max(s.peak, s.inFlight)
+		s.mu.Unlock()
+		defer func() {
+			s.mu.Lock()
+			s.inFlight--
+			s.mu.Unlock()
+		}()
+
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		// Hold each request so concurrent ones overlap
+		time.Sleep(20 * time.Millisecond)
+		fmt.Fprintf(w, "BEGIN:VCALENDAR\nBEGIN:VEVENT\nSUMMARY:%s\nDTSTART:20250311T090000Z\nEND:VEVENT\nEND:VCALENDAR", r.URL.Path)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestFetchAll(t *testing.T) {
+	parser := NewParser(time.UTC)
+
+	t.Run("results keep feed order", func(t *testing.T) {
+		server := newConcurrencyServer(t)
+		feeds := []Feed{
+			{ID: "a", Source: server.URL + "/a", IsURL: true},
+			{ID: "missing", Source: server.URL + "/missing", IsURL: true},
+			{ID: "c", Source: server.URL + "/c", IsURL: true},
+		}
+
+		fetcher := newTestFetcher()
+		fetcher.SetHostConcurrency(0)
+		results := fetcher.FetchAll(context.Background(), feeds, parser)
+		if len(results) != len(feeds) {
+			t.Fatalf("Expected %d results, got %d", len(feeds), len(results))
+		}
+		for i, result := range results {
+			if result.Feed.ID != feeds[i].ID {
+				t.Errorf("Result %d: expected feed %s, got %s", i, feeds[i].ID, result.Feed.ID)
+			}
+		}
+		if results[0].Err != nil || len(results[0].Events) != 1 || results[0].Events[0].Title != "/a" {
+			t.Errorf("Expected the event of feed a, got %+v", results[0])
+		}
+		if results[1].Err == nil || len(results[1].Events) != 0 {
+			t.Errorf("Expected an error for the missing feed, got %+v", results[1])
+		}
+		if results[2].Err != nil || len(results[2].Events) != 1 {
+			t.Errorf("Expected the event of feed c, got %+v", results[2])
+		}
+	})
+
+	limits := []struct {
+		name            string
+		concurrency     int
+		hostConcurrency int
+		peak            int
+	}{
+		{"overall limit", 2, 0, 2},
+		{"per-host limit", 4, 1, 1},
+	}
+	for _, tt := range limits {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newConcurrencyServer(t)
+			var feeds []Feed
+			for i := 0; i < 6; i++ {
+				feeds = append(feeds, Feed{Source: fmt.Sprintf("%s/%d", server.URL, i), IsURL: true})
+			}
+
+			fetcher := newTestFetcher()
+			fetcher.SetConcurrency(tt.concurrency)
+			fetcher.SetHostConcurrency(tt.hostConcurrency)
+			for _, result := range fetcher.FetchAll(context.Background(), feeds, parser) {
+				if result.Err != nil {
+					t.Errorf("Unexpected error: %v", result.Err)
+				}
+			}
+			if server.peak > tt.peak {
+				t.Errorf("Expected at most %d concurrent requests, got %d", tt.peak, server.peak)
+			}
+		})
+	}
+
+	t.Run("cancelled context", func(t *testing.T) {
+		server := newConcurrencyServer(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		results := newTestFetcher().FetchAll(ctx, []Feed{{Source: server.URL + "/a", IsURL: true}}, parser)
+		if results[0].Err == nil {
+			t.Error("Expected error for cancelled context")
+		}
+	})
+}
+
+func TestFeedHost(t *testing.T) {
+	tests := []struct {
+		feed     Feed
+		expected string
+	}{
+		{Feed{Source: "https://Calendar.Google.com/calendar/ical/a/basic.ics", IsURL: true}, "calendar.google.com"},
+		{Feed{Source: "http://localhost:8080/a.ics", IsURL: true}, "localhost"},
+		{Feed{Source: "/data/a.ics"}, ""},
+	}
+	for _, tt := range tests {
+		if got := feedHost(tt.feed); got != tt.expected {
+			t.Errorf("Expected host %q for %s, got %q", tt.expected, tt.feed.Source, got)
+		}
+	}
+}
@@ -0,0 +1,478 @@
+package calendar
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is the FREQ part of a recurrence rule
+type Frequency string
+
+const (
+	FrequencyDaily   Frequency = "DAILY"
+	FrequencyWeekly  Frequency = "WEEKLY"
+	FrequencyMonthly Frequency = "MONTHLY"
+	FrequencyYearly  Frequency = "YEARLY"
+)
+
+// maxRecurrencePeriods caps how many FREQ periods are walked for a single rule,
+// so rules that can never produce an instance (e.g. BYMONTHDAY=30;BYMONTH=2)
+// still terminate
+const maxRecurrencePeriods = 100000
+
+// WeekdayNum is a single BYDAY entry such as MO, 2TU or -1FR
+type WeekdayNum struct {
+	Ordinal int // 0 matches every such weekday in the period
+	Weekday time.Weekday
+}
+
+// RecurrenceRule represents a parsed RRULE value (RFC 5545 section 3.3.10)
+type RecurrenceRule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+	BySetPos   []int
+	WeekStart  time.Weekday
+}
+
+// ErrUnsupportedRule is returned for valid rules using parts or frequencies
+// that are not expanded, such as BYHOUR or FREQ=HOURLY
+var ErrUnsupportedRule = errors.New("unsupported recurrence rule")
+
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// ParseRecurrenceRule parses an RRULE value. Floating and date-only UNTIL
+// values are interpreted in loc.
+func ParseRecurrenceRule(value string, loc *time.Location) (*RecurrenceRule, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	rule := &RecurrenceRule{
+		Interval:  1,
+		WeekStart: time.Monday,
+	}
+	// Unsupported parts are only reported once the rest of the rule is
+	// known to be valid
+	var unsupported []string
+
+	for _, part := range strings.Split(strings.TrimSpace(value), ";") {
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("malformed rule part %q", part)
+		}
+		name, val := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+
+		var err error
+		switch name {
+		case "FREQ":
+			switch Frequency(val) {
+			case FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyYearly:
+				rule.Freq = Frequency(val)
+			case "SECONDLY", "MINUTELY", "HOURLY":
+				rule.Freq = Frequency(val)
+				unsupported = append(unsupported, part)
+			default:
+				return nil, fmt.Errorf("unsupported frequency %q", val)
+			}
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(val)
+			if err == nil && rule.Interval < 1 {
+				err = fmt.Errorf("must be positive")
+			}
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(val)
+			if err == nil && rule.Count < 1 {
+				err = fmt.Errorf("must be positive")
+			}
+		case "UNTIL":
+			rule.Until, err = parseUntil(val, loc)
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(val)
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseIntList(val, -31, 31)
+		case "BYMONTH":
+			var months []int
+			months, err = parseIntList(val, 1, 12)
+			for _, m := range months {
+				rule.ByMonth = append(rule.ByMonth, time.Month(m))
+			}
+		case "BYSETPOS":
+			rule.BySetPos, err = parseIntList(val, -366, 366)
+		case "WKST":
+			day, ok := weekdayCodes[val]
+			if !ok {
+				err = fmt.Errorf("unknown weekday")
+			}
+			rule.WeekStart = day
+		case "BYSECOND", "BYMINUTE", "BYHOUR", "BYYEARDAY", "BYWEEKNO":
+			unsupported = append(unsupported, name)
+		default:
+			return nil, fmt.Errorf("unknown rule part %s", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", name, val, err)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("missing FREQ")
+	}
+	if rule.Count > 0 && !rule.Until.IsZero() {
+		return nil, fmt.Errorf("COUNT and UNTIL are mutually exclusive")
+	}
+	if len(unsupported) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedRule, strings.Join(unsupported, ", "))
+	}
+
+	return rule, nil
+}
+
+func parseUntil(val string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", val); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("20060102T150405", val, loc); err == nil {
+		return t, nil
+	}
+	// A date-only UNTIL includes every instance starting on that day
+	if t, err := time.ParseInLocation("20060102", val, loc); err == nil {
+		return t.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognised date")
+}
+
+func parseByDay(val string) ([]WeekdayNum, error) {
+	var days []WeekdayNum
+	for _, item := range strings.Split(val, ",") {
+		if len(item) < 2 {
+			return nil, fmt.Errorf("malformed weekday %q", item)
+		}
+		code := item[len(item)-2:]
+		day, ok := weekdayCodes[code]
+		if !ok {
+			return nil, fmt.Errorf("unknown weekday %q", code)
+		}
+		wd := WeekdayNum{Weekday: day}
+		if prefix := item[:len(item)-2]; prefix != "" {
+			n, err := strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -53 || n > 53 {
+				return nil, fmt.Errorf("invalid ordinal %q", prefix)
+			}
+			wd.Ordinal = n
+		}
+		days = append(days, wd)
+	}
+	return days, nil
+}
+
+func parseIntList(val string, min, max int) ([]int, error) {
+	var values []int
+	for _, item := range strings.Split(val, ",") {
+		n, err := strconv.Atoi(item)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 || n < min || n > max {
+			return nil, fmt.Errorf("%d out of range", n)
+		}
+		values = append(values, n)
+	}
+	return values, nil
+}
+
+// Between returns the start times of every instance of the rule anchored at
+// dtstart that begin in [from, to). DTSTART itself is always the first
+// instance, as required by RFC 5545. Expansion stops at to, so open-ended
+// rules are safe to expand.
+func (r *RecurrenceRule) Between(dtstart, from, to time.Time) []time.Time {
+	var occurrences []time.Time
+	emit := func(t time.Time) {
+		if !t.Before(from) && t.Before(to) {
+			occurrences = append(occurrences, t)
+		}
+	}
+
+	if !r.Until.IsZero() && dtstart.After(r.Until) {
+		return nil
+	}
+	emit(dtstart)
+	count := 1
+	if r.Count > 0 && count >= r.Count {
+		return occurrences
+	}
+
+	for n := 0; n < maxRecurrencePeriods; n++ {
+		periodStart, candidates := r.period(dtstart, n)
+		if !periodStart.Before(to) {
+			break
+		}
+
+		for _, t := range candidates {
+			if !t.After(dtstart) {
+				continue
+			}
+			if !r.Until.IsZero() && t.After(r.Until) {
+				return occurrences
+			}
+			emit(t)
+			count++
+			if r.Count > 0 && count >= r.Count {
+				return occurrences
+			}
+		}
+	}
+
+	return occurrences
+}
+
+// period returns the start of the nth FREQ period after the one containing
+// dtstart together with the sorted instance start times it produces
+func (r *RecurrenceRule) period(dtstart time.Time, n int) (time.Time, []time.Time) {
+	loc := dtstart.Location()
+	y, m, d := dtstart.Date()
+	step := n * r.Interval
+
+	var start time.Time
+	var days []time.Time
+
+	switch r.Freq {
+	case FrequencyDaily:
+		start = time.Date(y, m, d+step, 0, 0, 0, 0, loc)
+		if r.matchesDay(start) {
+			days = append(days, start)
+		}
+	case FrequencyWeekly:
+		offset := (int(dtstart.Weekday()) - int(r.WeekStart) + 7) % 7
+		start = time.Date(y, m, d-offset+7*step, 0, 0, 0, 0, loc)
+		for i := 0; i < 7; i++ {
+			day := start.AddDate(0, 0, i)
+			if r.matchesWeekDay(day, dtstart) && r.matchesMonth(day.Month()) {
+				days = append(days, day)
+			}
+		}
+	case FrequencyMonthly:
+		start = time.Date(y, m+time.Month(step), 1, 0, 0, 0, 0, loc)
+		if r.matchesMonth(start.Month()) {
+			days = r.monthDays(start, dtstart)
+		}
+	case FrequencyYearly:
+		start = time.Date(y+step, time.January, 1, 0, 0, 0, 0, loc)
+		days = r.yearDays(start, dtstart)
+	}
+
+	days = r.applySetPos(days)
+
+	hour, min, sec := dtstart.Clock()
+	instances := make([]time.Time, 0, len(days))
+	for _, day := range days {
+		instances = append(instances, time.Date(day.Year(), day.Month(), day.Day(), hour, min, sec, 0, loc))
+	}
+	return start, instances
+}
+
+// matchesDay applies BYMONTH, BYMONTHDAY and BYDAY as filters (DAILY rules)
+func (r *RecurrenceRule) matchesDay(day time.Time) bool {
+	if !r.matchesMonth(day.Month()) {
+		return false
+	}
+	if len(r.ByMonthDay) > 0 && !r.matchesMonthDay(day) {
+		return false
+	}
+	if len(r.ByDay) > 0 {
+		for _, wd := range r.ByDay {
+			if wd.Weekday == day.Weekday() {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// matchesWeekDay reports whether day is selected within a WEEKLY period
+func (r *RecurrenceRule) matchesWeekDay(day, dtstart time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return day.Weekday() == dtstart.Weekday()
+	}
+	for _, wd := range r.ByDay {
+		if wd.Weekday == day.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RecurrenceRule) matchesMonth(month time.Month) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, m := range r.ByMonth {
+		if m == month {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RecurrenceRule) matchesMonthDay(day time.Time) bool {
+	dim := daysInMonth(day.Year(), day.Month())
+	for _, md := range r.ByMonthDay {
+		if md < 0 {
+			md = dim + md + 1
+		}
+		if md == day.Day() {
+			return true
+		}
+	}
+	return false
+}
+
+// monthDays expands BYMONTHDAY and BYDAY within the month starting at first.
+// Without either, the day of month of dtstart is used.
+func (r *RecurrenceRule) monthDays(first, dtstart time.Time) []time.Time {
+	dim := daysInMonth(first.Year(), first.Month())
+
+	if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+		if dtstart.Day() > dim {
+			return nil
+		}
+		return []time.Time{first.AddDate(0, 0, dtstart.Day()-1)}
+	}
+
+	var days []time.Time
+	for i := 0; i < dim; i++ {
+		day := first.AddDate(0, 0, i)
+		if len(r.ByMonthDay) > 0 && !r.matchesMonthDay(day) {
+			continue
+		}
+		if len(r.ByDay) > 0 && !matchesOrdinalWeekday(r.ByDay, day, first, dim) {
+			continue
+		}
+		days = append(days, day)
+	}
+	return days
+}
+
+// yearDays expands a YEARLY period starting at first
+func (r *RecurrenceRule) yearDays(first, dtstart time.Time) []time.Time {
+	var days []time.Time
+
+	switch {
+	case len(r.ByMonth) > 0:
+		// BYDAY ordinals are relative to the month when BYMONTH is present
+		for _, month := range sortedMonths(r.ByMonth) {
+			monthStart := time.Date(first.Year(), month, 1, 0, 0, 0, 0, first.Location())
+			if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+				if dtstart.Day() <= daysInMonth(first.Year(), month) {
+					days = append(days, monthStart.AddDate(0, 0, dtstart.Day()-1))
+				}
+				continue
+			}
+			days = append(days, r.monthDays(monthStart, dtstart)...)
+		}
+	case len(r.ByMonthDay) > 0:
+		for month := time.January; month <= time.December; month++ {
+			monthStart := time.Date(first.Year(), month, 1, 0, 0, 0, 0, first.Location())
+			days = append(days, r.monthDays(monthStart, dtstart)...)
+		}
+	case len(r.ByDay) > 0:
+		// BYDAY ordinals are relative to the whole year
+		daysInYear := 365
+		if isLeapYear(first.Year()) {
+			daysInYear = 366
+		}
+		for i := 0; i < daysInYear; i++ {
+			day := first.AddDate(0, 0, i)
+			if matchesOrdinalWeekday(r.ByDay, day, first, daysInYear) {
+				days = append(days, day)
+			}
+		}
+	default:
+		if dtstart.Day() <= daysInMonth(first.Year(), dtstart.Month()) {
+			days = append(days, time.Date(first.Year(), dtstart.Month(), dtstart.Day(), 0, 0, 0, 0, first.Location()))
+		}
+	}
+
+	return days
+}
+
+// applySetPos keeps only the BYSETPOS positions of the period's sorted days
+func (r *RecurrenceRule) applySetPos(days []time.Time) []time.Time {
+	if len(r.BySetPos) == 0 || len(days) == 0 {
+		return days
+	}
+
+	selected := make(map[int]bool)
+	for _, pos := range r.BySetPos {
+		idx := pos - 1
+		if pos < 0 {
+			idx = len(days) + pos
+		}
+		if idx >= 0 && idx < len(days) {
+			selected[idx] = true
+		}
+	}
+
+	var result []time.Time
+	for i, day := range days {
+		if selected[i] {
+			result = append(result, day)
+		}
+	}
+	return result
+}
+
+// matchesOrdinalWeekday reports whether day matches one of the BYDAY entries,
+// where ordinals count weekdays from first across a span of length days
+func matchesOrdinalWeekday(byDay []WeekdayNum, day, first time.Time, length int) bool {
+	index := day.YearDay() - first.YearDay() // zero-based offset into the span
+	for _, wd := range byDay {
+		if wd.Weekday != day.Weekday() {
+			continue
+		}
+		if wd.Ordinal == 0 {
+			return true
+		}
+		if wd.Ordinal > 0 && index/7+1 == wd.Ordinal {
+			return true
+		}
+		if wd.Ordinal < 0 && (length-1-index)/7+1 == -wd.Ordinal {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedMonths(months []time.Month) []time.Month {
+	sorted := append([]time.Month(nil), months...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
+
+func daysInMonth(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func isLeapYear(year int) bool {
+	return daysInMonth(year, time.February) == 29
+}
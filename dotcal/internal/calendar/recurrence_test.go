@@ -0,0 +1,253 @@
+package calendar
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseRecurrenceRule(t *testing.T) {
+	t.Run("full rule", func(t *testing.T) {
+		rule, err := ParseRecurrenceRule("FREQ=MONTHLY;INTERVAL=2;COUNT=5;BYDAY=2TU,-1FR;BYSETPOS=1;WKST=SU", time.UTC)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if rule.Freq != FrequencyMonthly {
+			t.Errorf("Expected MONTHLY, got %v", rule.Freq)
+		}
+		if rule.Interval != 2 || rule.Count != 5 {
+			t.Errorf("Expected interval 2 and count 5, got %d and %d", rule.Interval, rule.Count)
+		}
+		expectedDays := []WeekdayNum{{2, time.Tuesday}, {-1, time.Friday}}
+		if len(rule.ByDay) != len(expectedDays) {
+			t.Fatalf("Expected %d BYDAY entries, got %d", len(expectedDays), len(rule.ByDay))
+		}
+		for i, wd := range expectedDays {
+			if rule.ByDay[i] != wd {
+				t.Errorf("Expected BYDAY %v, got %v", wd, rule.ByDay[i])
+			}
+		}
+		if rule.WeekStart != time.Sunday {
+			t.Errorf("Expected WKST Sunday, got %v", rule.WeekStart)
+		}
+	})
+
+	t.Run("date-only until is inclusive", func(t *testing.T) {
+		rule, err := ParseRecurrenceRule("FREQ=DAILY;UNTIL=20250305", time.UTC)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !rule.Until.After(time.Date(2025, 3, 5, 23, 0, 0, 0, time.UTC)) {
+			t.Errorf("Expected UNTIL to cover the whole day, got %v", rule.Until)
+		}
+	})
+
+	invalid := []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=SOMETIMES",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20250301T000000Z",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=WEEKLY;BYWHENEVER=1",
+		"FREQ=DAILY;BYHOUR=9;INTERVAL=0",
+	}
+	for _, value := range invalid {
+		_, err := ParseRecurrenceRule(value, time.UTC)
+		if err == nil || errors.Is(err, ErrUnsupportedRule) {
+			t.Errorf("Expected invalid rule error for %q, got %v", value, err)
+		}
+	}
+
+	unsupported := []string{
+		"FREQ=HOURLY",
+		"FREQ=YEARLY;BYWEEKNO=20",
+		"FREQ=YEARLY;BYYEARDAY=100",
+		"FREQ=DAILY;BYHOUR=9,14;BYMINUTE=30",
+	}
+	for _, value := range unsupported {
+		if _, err := ParseRecurrenceRule(value, time.UTC); !errors.Is(err, ErrUnsupportedRule) {
+			t.Errorf("Expected unsupported rule error for %q, got %v", value, err)
+		}
+	}
+}
+
+func TestRecurrenceBetween(t *testing.T) {
+	date := func(y int, m time.Month, d, h, min int) time.Time {
+		return time.Date(y, m, d, h, min, 0, 0, time.UTC)
+	}
+	farFuture := date(2030, 1, 1, 0, 0)
+
+	tests := []struct {
+		name     string
+		rule     string
+		dtstart  time.Time
+		from     time.Time
+		to       time.Time
+		expected []time.Time
+	}{
+		{
+			name:    "daily with count",
+			rule:    "FREQ=DAILY;COUNT=3",
+			dtstart: date(2025, 3, 10, 9, 0),
+			to:      farFuture,
+			expected: []time.Time{
+				date(2025, 3, 10, 9, 0), date(2025, 3, 11, 9, 0), date(2025, 3, 12, 9, 0),
+			},
+		},
+		{
+			name:    "weekly on multiple days with until",
+			rule:    "FREQ=WEEKLY;BYDAY=MO,WE;UNTIL=20250319T090000Z",
+			dtstart: date(2025, 3, 10, 9, 0),
+			to:      farFuture,
+			expected: []time.Time{
+				date(2025, 3, 10, 9, 0), date(2025, 3, 12, 9, 0),
+				date(2025, 3, 17, 9, 0), date(2025, 3, 19, 9, 0),
+			},
+		},
+		{
+			name:    "biweekly",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;COUNT=3",
+			dtstart: date(2025, 3, 11, 14, 0),
+			to:      farFuture,
+			expected: []time.Time{
+				date(2025, 3, 11, 14, 0), date(2025, 3, 25, 14, 0), date(2025, 4, 8, 14, 0),
+			},
+		},
+		{
+			name:    "monthly second tuesday",
+			rule:    "FREQ=MONTHLY;BYDAY=2TU;COUNT=3",
+			dtstart: date(2025, 1, 14, 10, 0),
+			to:      farFuture,
+			expected: []time.Time{
+				date(2025, 1, 14, 10, 0), date(2025, 2, 11, 10, 0), date(2025, 3, 11, 10, 0),
+			},
+		},
+		{
+			name:    "monthly last friday",
+			rule:    "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3",
+			dtstart: date(2025, 1, 31, 16, 0),
+			to:      farFuture,
+			expected: []time.Time{
+				date(2025, 1, 31, 16, 0), date(2025, 2, 28, 16, 0), date(2025, 3, 28, 16, 0),
+			},
+		},
+		{
+			name:    "monthly on the 31st skips short months",
+			rule:    "FREQ=MONTHLY;COUNT=3",
+			dtstart: date(2025, 1, 31, 9, 0),
+			to:      farFuture,
+			expected: []time.Time{
+				date(2025, 1, 31, 9, 0), date(2025, 3, 31, 9, 0), date(2025, 5, 31, 9, 0),
+			},
+		},
+		{
+			name:    "negative month day",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=3",
+			dtstart: date(2025, 1, 31, 9, 0),
+			to:      farFuture,
+			expected: []time.Time{
+				date(2025, 1, 31, 9, 0), date(2025, 2, 28, 9, 0), date(2025, 3, 31, 9, 0),
+			},
+		},
+		{
+			name:    "last weekday of month via BYSETPOS",
+			rule:    "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1;COUNT=3",
+			dtstart: date(2025, 1, 31, 9, 0),
+			to:      farFuture,
+			expected: []time.Time{
+				date(2025, 1, 31, 9, 0), date(2025, 2, 28, 9, 0), date(2025, 3, 31, 9, 0),
+			},
+		},
+		{
+			name:    "yearly with BYMONTH and ordinal weekday",
+			rule:    "FREQ=YEARLY;BYMONTH=11;BYDAY=4TH;COUNT=2",
+			dtstart: date(2025, 11, 27, 12, 0),
+			to:      farFuture,
+			expected: []time.Time{
+				date(2025, 11, 27, 12, 0), date(2026, 11, 26, 12, 0),
+			},
+		},
+		{
+			name:    "yearly leap day",
+			rule:    "FREQ=YEARLY;COUNT=2",
+			dtstart: date(2024, 2, 29, 9, 0),
+			to:      farFuture,
+			expected: []time.Time{
+				date(2024, 2, 29, 9, 0), date(2028, 2, 29, 9, 0),
+			},
+		},
+		{
+			name:    "biweekly grouping with default week start",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU",
+			dtstart: date(1997, 8, 5, 9, 0),
+			to:      farFuture,
+			expected: []time.Time{
+				date(1997, 8, 5, 9, 0), date(1997, 8, 10, 9, 0),
+				date(1997, 8, 19, 9, 0), date(1997, 8, 24, 9, 0),
+			},
+		},
+		{
+			name:    "week start changes biweekly grouping",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=SU",
+			dtstart: date(1997, 8, 5, 9, 0),
+			to:      farFuture,
+			expected: []time.Time{
+				date(1997, 8, 5, 9, 0), date(1997, 8, 17, 9, 0),
+				date(1997, 8, 19, 9, 0), date(1997, 8, 31, 9, 0),
+			},
+		},
+		{
+			name:    "open-ended rule is bounded by window",
+			rule:    "FREQ=WEEKLY",
+			dtstart: date(2020, 1, 6, 9, 0),
+			from:    date(2025, 3, 1, 0, 0),
+			to:      date(2025, 3, 15, 0, 0),
+			expected: []time.Time{
+				date(2025, 3, 3, 9, 0), date(2025, 3, 10, 9, 0),
+			},
+		},
+		{
+			name:     "impossible rule terminates",
+			rule:     "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30",
+			dtstart:  date(2025, 1, 30, 9, 0),
+			from:     date(2025, 2, 1, 0, 0),
+			to:       farFuture,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRecurrenceRule(tt.rule, time.UTC)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			got := rule.Between(tt.dtstart, tt.from, tt.to)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Expected %d occurrences, got %d: %v", len(tt.expected), len(got), got)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.expected[i]) {
+					t.Errorf("Occurrence %d: expected %v, got %v", i, tt.expected[i], got[i])
+				}
+			}
+		})
+	}
+
+	t.Run("keeps wall clock time across DST", func(t *testing.T) {
+		nyc, err := time.LoadLocation("America/New_York")
+		if err != nil {
+			t.Skip("timezone data unavailable")
+		}
+		rule, _ := ParseRecurrenceRule("FREQ=WEEKLY;COUNT=2", nyc)
+		got := rule.Between(time.Date(2025, 3, 6, 9, 0, 0, 0, nyc), time.Time{}, farFuture)
+		if len(got) != 2 {
+			t.Fatalf("Expected 2 occurrences, got %d", len(got))
+		}
+		if got[1].Hour() != 9 {
+			t.Errorf("Expected 9 AM local after DST change, got %v", got[1])
+		}
+	})
+}
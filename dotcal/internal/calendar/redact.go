@@ -0,0 +1,88 @@
+package calendar
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// redacted replaces secrets in feed URLs
+const redacted = "REDACTED"
+
+// secretParams are query parameters that carry credentials
+var secretParams = map[string]bool{
+	"access_token": true,
+	"api_key":      true,
+	"apikey":       true,
+	"auth":         true,
+	"key":          true,
+	"password":     true,
+	"secret":       true,
+	"sig":          true,
+	"signature":    true,
+	"token":        true,
+}
+
+// RedactURL hides the secrets a feed URL may carry so it can be logged:
+// user info, credential query parameters and the private-<key> path segment
+// of Google Calendar's secret addresses. Commands of exec: sources keep only
+// their name, as their arguments may hold credentials. Other values that are
+// not URLs, such as file paths, are returned unchanged.
+func RedactURL(source string) string {
+	if command, ok := strings.CutPrefix(source, "exec:"); ok {
+		fields := strings.Fields(command)
+		if len(fields) > 1 {
+			return "exec:" + fields[0] + " " + redacted
+		}
+		return source
+	}
+
+	u, err := url.Parse(source)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return source
+	}
+
+	if u.User != nil {
+		u.User = url.User(redacted)
+	}
+
+	if u.RawQuery != "" {
+		query := u.Query()
+		for name := range query {
+			if secretParams[strings.ToLower(name)] {
+				query.Set(name, redacted)
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+
+	// Work on the escaped path so escapes such as %40 are kept
+	segments := strings.Split(u.EscapedPath(), "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "private-") {
+			segments[i] = "private-" + redacted
+		}
+	}
+	if path, err := url.PathUnescape(strings.Join(segments, "/")); err == nil {
+		u.Path, u.RawPath = path, strings.Join(segments, "/")
+	}
+
+	return u.String()
+}
+
+// String identifies the feed in logs without revealing secrets in its source
+func (f Feed) String() string {
+	if f.ID == "" {
+		return RedactURL(f.Source)
+	}
+	return f.ID + " (" + RedactURL(f.Source) + ")"
+}
+
+// redactError hides secrets in the URL of a request error
+func redactError(err error) error {
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		uerr.URL = RedactURL(uerr.URL)
+	}
+	return err
+}
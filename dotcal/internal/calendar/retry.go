@@ -0,0 +1,93 @@
+package calendar
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how failed feed requests are retried. Network errors,
+// 429 Too Many Requests and 5xx responses are retried; other responses are
+// final.
+type RetryPolicy struct {
+	MaxAttempts    int           // Total attempts including the first; 1 disables retries
+	InitialBackoff time.Duration // Wait before the first retry, doubled for each further retry
+	MaxBackoff     time.Duration // Longest single wait, including one requested by Retry-After
+}
+
+// DefaultRetryPolicy returns the policy used by new fetchers
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Second,
+		MaxBackoff:     30 * time.Second,
+	}
+}
+
+// retryWait reports whether a failed attempt should be retried and how long
+// to wait first. retry is 1 for the first retry.
+func (p RetryPolicy) retryWait(resp *http.Response, err error, retry int) (bool, time.Duration) {
+	if retry >= p.MaxAttempts {
+		return false, 0
+	}
+
+	if err != nil {
+		// The feed's own deadline is not worth retrying
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false, 0
+		}
+		// Nor is a certificate that will be rejected again
+		var certErr *tls.CertificateVerificationError
+		if errors.As(err, &certErr) {
+			return false, 0
+		}
+		return true, p.backoff(retry)
+	}
+
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+		return false, 0
+	}
+	if wait, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+		// Retrying earlier than the server asked would only be refused again
+		if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+			return false, 0
+		}
+		return true, wait
+	}
+	return true, p.backoff(retry)
+}
+
+// backoff returns the wait before a retry: exponential, capped by
+// MaxBackoff, with half of it randomized so feeds do not retry in lockstep
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < retry && (p.MaxBackoff <= 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + rand.N(d-half+1)
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
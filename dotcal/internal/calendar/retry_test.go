@@ -0,0 +1,92 @@
+package calendar
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestRetryWait(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	response := func(status int, retryAfter string) *http.Response {
+		resp := &http.Response{StatusCode: status, Header: make(http.Header)}
+		if retryAfter != "" {
+			resp.Header.Set("Retry-After", retryAfter)
+		}
+		return resp
+	}
+
+	tests := []struct {
+		name  string
+		resp  *http.Response
+		err   error
+		retry int
+		again bool
+		wait  time.Duration // exact wait, or 0 to only check the retry decision
+	}{
+		{"network error", nil, errors.New("connection reset"), 1, true, 0},
+		{"deadline", nil, context.DeadlineExceeded, 1, false, 0},
+		{"untrusted certificate", nil, &url.Error{Op: "Get", Err: &tls.CertificateVerificationError{}}, 1, false, 0},
+		{"bad gateway", response(http.StatusBadGateway, ""), nil, 1, true, 0},
+		{"too many requests", response(http.StatusTooManyRequests, "1"), nil, 1, true, time.Second},
+		{"retry after too long", response(http.StatusServiceUnavailable, "5"), nil, 1, false, 0},
+		{"not found", response(http.StatusNotFound, ""), nil, 1, false, 0},
+		{"last attempt", response(http.StatusBadGateway, ""), nil, 3, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			again, wait := policy.retryWait(tt.resp, tt.err, tt.retry)
+			if again != tt.again {
+				t.Errorf("Expected retry %v, got %v", tt.again, again)
+			}
+			if tt.wait != 0 && wait != tt.wait {
+				t.Errorf("Expected wait %v, got %v", tt.wait, wait)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	tests := []struct {
+		retry int
+		max   time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{8, time.Second},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			wait := policy.backoff(tt.retry)
+			if wait < tt.max/2 || wait > tt.max {
+				t.Errorf("Retry %d: expected wait between %v and %v, got %v", tt.retry, tt.max/2, tt.max, wait)
+			}
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	if wait, ok := retryAfter("30"); !ok || wait != 30*time.Second {
+		t.Errorf("Expected 30s, got %v (%v)", wait, ok)
+	}
+
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if wait, ok := retryAfter(date); !ok || wait <= 0 || wait > time.Minute {
+		t.Errorf("Expected about a minute for %s, got %v (%v)", date, wait, ok)
+	}
+
+	for _, value := range []string{"", "soon", "-5"} {
+		if _, ok := retryAfter(value); ok {
+			t.Errorf("Expected %q to be rejected", value)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package calendar
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Source reads feeds whose source starts with the scheme it is registered
+// for, such as exec:command
+type Source interface {
+	Open(ctx context.Context, feed Feed) (io.ReadCloser, error)
+}
+
+// SetSource registers a source for a scheme, replacing any existing one.
+// The built-in "exec" source runs exec:command through sh and reads its
+// output; "stdin" reads standard input for a source of "-".
+func (f *Fetcher) SetSource(scheme string, source Source) {
+	f.sources[strings.ToLower(scheme)] = source
+}
+
+// IsURLSource reports whether a feed source is an HTTP or webcal URL
+func IsURLSource(source string) bool {
+	switch sourceScheme(source) {
+	case "http", "https", "webcal", "webcals":
+		return true
+	default:
+		return false
+	}
+}
+
+// sourceScheme returns the lower-cased scheme of a feed source, "stdin" for
+// "-" and "" for plain file paths
+func sourceScheme(source string) string {
+	if source == "-" {
+		return "stdin"
+	}
+	// A single letter is a Windows drive rather than a scheme
+	i := strings.IndexByte(source, ':')
+	if i < 2 {
+		return ""
+	}
+	for _, c := range source[:i] {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '+' || c == '-' || c == '.') {
+			return ""
+		}
+	}
+	return strings.ToLower(source[:i])
+}
+
+// httpURL rewrites the webcal:// links of subscribe buttons to the HTTP URL
+// they stand for
+func httpURL(source string) string {
+	switch sourceScheme(source) {
+	case "webcal":
+		return "http" + source[len("webcal"):]
+	case "webcals":
+		return "https" + source[len("webcals"):]
+	default:
+		return source
+	}
+}
+
+// expandPath returns the files a local source names: the file itself, the
+// calendar files of a directory or the matches of a glob pattern
+func expandPath(path string) ([]string, error) {
+	if strings.ContainsAny(path, "*?[") {
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %s", path)
+		}
+		sort.Strings(matches)
+		return matches, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && formatFromPath(entry.Name()) != "" {
+			files = append(files, filepath.Join(path, entry.Name()))
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no calendar files in %s", path)
+	}
+	return files, nil
+}
+
+// fetchFiles parses every file a local source names. Each file is parsed on
+// its own, so parse errors name the file they were found in.
+func (f *Fetcher) fetchFiles(feed Feed, parser *Parser) (*FeedResult, error) {
+	paths, err := expandPath(feed.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &FeedResult{Feed: feed, FetchedAt: time.Now()}
+	for _, path := range paths {
+		fileFeed := feed
+		if fileFeed.Format == "" {
+			fileFeed.Format = formatFromPath(path)
+		}
+		if len(paths) > 1 {
+			fileFeed.ID = feed.ID + ":" + filepath.Base(path)
+		}
+
+		resp, err := f.openFile(path)
+		if err != nil {
+			return nil, err
+		}
+		r, err := resp.payload()
+		if err == nil {
+			err = result.parse(parser, fileFeed, r)
+		}
+		resp.body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+	}
+	return result, nil
+}
+
+// stdinSource reads a feed from standard input
+type stdinSource struct {
+	r io.Reader
+}
+
+func (s stdinSource) Open(ctx context.Context, feed Feed) (io.ReadCloser, error) {
+	return io.NopCloser(s.r), nil
+}
+
+// commandSource runs the command of an exec: source and reads its output
+type commandSource struct{}
+
+func (commandSource) Open(ctx context.Context, feed Feed) (io.ReadCloser, error) {
+	command := strings.TrimSpace(feed.Source[len("exec:"):])
+	if command == "" {
+		return nil, fmt.Errorf("exec source without a command")
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	out := &commandOutput{cmd: cmd}
+	cmd.Stderr = &out.stderr
+	// Killing the shell leaves its children holding the pipes open, so
+	// neither wait for them to close nor keep reading once ctx is done
+	cmd.WaitDelay = time.Second
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to run command: %w", err)
+	}
+	out.ctx = ctx
+	out.stdout = stdout
+	out.stop = context.AfterFunc(ctx, func() { stdout.Close() })
+	return out, nil
+}
+
+// commandOutput reads a command's output, reporting a failed command in
+// place of the end of its output
+type commandOutput struct {
+	cmd    *exec.Cmd
+	ctx    context.Context
+	stdout io.Reader
+	stderr bytes.Buffer
+	stop   func() bool
+	done   bool
+	err    error // io.EOF or why the command failed, once it is done
+}
+
+func (c *commandOutput) Read(p []byte) (int, error) {
+	if c.done {
+		return 0, c.err
+	}
+	n, err := c.stdout.Read(p)
+	switch {
+	case err == io.EOF:
+		c.wait()
+		return n, c.err
+	case err != nil && c.ctx.Err() != nil:
+		c.Close()
+		return n, c.ctx.Err()
+	}
+	return n, err
+}
+
+func (c *commandOutput) Close() error {
+	if !c.done {
+		c.cmd.Process.Kill()
+		c.wait()
+	}
+	return nil
+}
+
+// wait reaps the command and records whether it failed
+func (c *commandOutput) wait() {
+	c.done = true
+	c.stop()
+	c.err = io.EOF
+	if err := c.cmd.Wait(); err != nil {
+		if msg := strings.TrimSpace(c.stderr.String()); msg != "" {
+			c.err = fmt.Errorf("command failed: %w: %s", err, msg)
+		} else {
+			c.err = fmt.Errorf("command failed: %w", err)
+		}
+	}
+}
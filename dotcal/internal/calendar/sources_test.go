@@ -0,0 +1,175 @@
+package calendar
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sourceEvent(title string) string {
+	return "BEGIN:VCALENDAR\nBEGIN:VEVENT\nSUMMARY:" + title +
+		"\nDTSTART:20250311T090000Z\nDTEND:20250311T100000Z\nEND:VEVENT\nEND:VCALENDAR\n"
+}
+
+func TestSourceScheme(t *testing.T) {
+	tests := map[string]string{
+		"https://example.com/a.ics":  "https",
+		"WEBCAL://example.com/a.ics": "webcal",
+		"exec:cat a.ics":             "exec",
+		"-":                          "stdin",
+		"/data/a.ics":                "",
+		`C:\data\a.ics`:              "",
+		"a.ics":                      "",
+	}
+	for source, expected := range tests {
+		if got := sourceScheme(source); got != expected {
+			t.Errorf("Expected scheme %q for %q, got %q", expected, source, got)
+		}
+	}
+
+	if !IsURLSource("webcals://example.com/a.ics") || IsURLSource("exec:curl https://example.com") {
+		t.Error("Expected only HTTP and webcal sources to be URLs")
+	}
+}
+
+func TestHTTPURL(t *testing.T) {
+	tests := map[string]string{
+		"webcal://example.com/a.ics":  "http://example.com/a.ics",
+		"webcals://example.com/a.ics": "https://example.com/a.ics",
+		"https://example.com/a.ics":   "https://example.com/a.ics",
+	}
+	for source, expected := range tests {
+		if got := httpURL(source); got != expected {
+			t.Errorf("Expected %q for %q, got %q", expected, source, got)
+		}
+	}
+}
+
+func TestFetchSources(t *testing.T) {
+	fetcher := newTestFetcher()
+	parser := NewParser(time.UTC)
+
+	t.Run("webcal", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(sourceEvent("Subscribed")))
+		}))
+		defer server.Close()
+
+		source := "webcal" + strings.TrimPrefix(server.URL, "http")
+		result, err := fetcher.FetchEvents(Feed{Source: source, IsURL: IsURLSource(source)}, parser)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(result.Events) != 1 || result.Events[0].Title != "Subscribed" {
+			t.Errorf("Expected the subscribed event, got %v", result.Events)
+		}
+	})
+
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"a.ics":      sourceEvent("First"),
+		"b.ics":      sourceEvent("Second") + "BEGIN:VEVENT\nDTSTART:yesterday\nEND:VEVENT\n",
+		"notes.txt":  "not a calendar",
+		"export.xml": `<icalendar xmlns="urn:ietf:params:xml:ns:icalendar-2.0"/>`,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	t.Run("directory", func(t *testing.T) {
+		result, err := fetcher.FetchEvents(Feed{ID: "exports", Source: dir}, parser)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(result.Events) != 2 || result.Events[0].Title != "First" || result.Events[1].Title != "Second" {
+			t.Errorf("Expected the events of both files in order, got %v", result.Events)
+		}
+		if len(result.ParseErrors) != 1 || result.ParseErrors[0].FeedID != "exports:b.ics" {
+			t.Errorf("Expected the parse error to name its file, got %v", result.ParseErrors)
+		}
+	})
+
+	t.Run("glob", func(t *testing.T) {
+		result, err := fetcher.FetchEvents(Feed{Source: filepath.Join(dir, "a*.ics")}, parser)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(result.Events) != 1 || result.Events[0].Title != "First" {
+			t.Errorf("Expected only the matching file, got %v", result.Events)
+		}
+
+		if _, err := fetcher.FetchEvents(Feed{Source: filepath.Join(dir, "*.json")}, parser); err == nil {
+			t.Error("Expected error when nothing matches")
+		}
+	})
+
+	t.Run("exec", func(t *testing.T) {
+		source := "exec:cat " + filepath.Join(dir, "a.ics")
+		result, err := fetcher.FetchEvents(Feed{Source: source}, parser)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(result.Events) != 1 || result.Events[0].Title != "First" {
+			t.Errorf("Expected the command's event, got %v", result.Events)
+		}
+	})
+
+	t.Run("failing command", func(t *testing.T) {
+		_, err := fetcher.Fetch(Feed{Source: "exec:echo token expired >&2; exit 3"})
+		if err == nil || !strings.Contains(err.Error(), "token expired") {
+			t.Errorf("Expected the command's error output, got %v", err)
+		}
+	})
+
+	t.Run("command timeout", func(t *testing.T) {
+		start := time.Now()
+		_, err := fetcher.Fetch(Feed{Source: "exec:sleep 10", Timeout: 50 * time.Millisecond})
+		if err == nil {
+			t.Error("Expected error for a command that outlives the timeout")
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("Expected the command to be stopped, took %s", elapsed)
+		}
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		fetcher := newTestFetcher()
+		fetcher.SetSource("stdin", stdinSource{r: strings.NewReader(sourceEvent("Piped"))})
+
+		result, err := fetcher.FetchEvents(Feed{Source: "-"}, parser)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(result.Events) != 1 || result.Events[0].Title != "Piped" {
+			t.Errorf("Expected the piped event, got %v", result.Events)
+		}
+	})
+
+	t.Run("custom source", func(t *testing.T) {
+		fetcher := newTestFetcher()
+		fetcher.SetSource("vault", sourceFunc(func(ctx context.Context, feed Feed) (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(sourceEvent(feed.Source))), nil
+		}))
+
+		data, err := fetcher.Fetch(Feed{Source: "vault:team"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !strings.Contains(string(data), "SUMMARY:vault:team") {
+			t.Errorf("Expected data from the custom source, got %q", data)
+		}
+	})
+}
+
+type sourceFunc func(ctx context.Context, feed Feed) (io.ReadCloser, error)
+
+func (s sourceFunc) Open(ctx context.Context, feed Feed) (io.ReadCloser, error) {
+	return s(ctx, feed)
+}
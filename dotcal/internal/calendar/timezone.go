@@ -0,0 +1,386 @@
+package calendar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// vtimezoneHorizon is the last year for which VTIMEZONE transitions are computed
+const vtimezoneHorizon = 2100
+
+// vtimezone is a VTIMEZONE component embedded in a feed
+type vtimezone struct {
+	tzid        string
+	observances []*observance
+}
+
+// observance is a STANDARD or DAYLIGHT sub-component of a VTIMEZONE
+type observance struct {
+	daylight   bool
+	name       string
+	dtstart    string // local time in the TZOFFSETFROM offset
+	offsetFrom int    // seconds east of UTC
+	offsetTo   int
+	rrule      string
+	rdates     []string
+}
+
+var (
+	zoneCacheMu sync.Mutex
+	zoneCache   = make(map[string]*time.Location)
+)
+
+// LookupZone resolves a TZID against the IANA database, falling back to the
+// Windows zone names emitted by Outlook and Exchange. It returns nil when the
+// zone is unknown.
+func LookupZone(tzid string) *time.Location {
+	tzid = strings.Trim(tzid, "\" ")
+	if tzid == "" {
+		return nil
+	}
+
+	zoneCacheMu.Lock()
+	defer zoneCacheMu.Unlock()
+
+	if loc, ok := zoneCache[tzid]; ok {
+		return loc
+	}
+
+	var loc *time.Location
+	for _, name := range zoneCandidates(tzid) {
+		if l, err := time.LoadLocation(name); err == nil {
+			loc = l
+			break
+		}
+	}
+	zoneCache[tzid] = loc
+	return loc
+}
+
+// zoneCandidates lists the IANA names worth trying for a TZID
+func zoneCandidates(tzid string) []string {
+	candidates := []string{tzid}
+	if iana, ok := windowsZones[tzid]; ok {
+		candidates = append(candidates, iana)
+	}
+	// Some producers prefix IANA names, e.g. /mozilla.org/20050126_1/America/New_York
+	if strings.HasPrefix(tzid, "/") {
+		parts := strings.Split(strings.Trim(tzid, "/"), "/")
+		for i := 1; i < len(parts); i++ {
+			candidates = append(candidates, strings.Join(parts[i:], "/"))
+		}
+	}
+	return candidates
+}
+
+// location builds a time.Location from the VTIMEZONE's observances
+func (tz *vtimezone) location() (*time.Location, error) {
+	if len(tz.observances) == 0 {
+		return nil, fmt.Errorf("VTIMEZONE %s has no observances", tz.tzid)
+	}
+
+	type transition struct {
+		when int64
+		zone int
+	}
+	var zones []*observance
+	var transitions []transition
+	horizon := time.Date(vtimezoneHorizon, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, obs := range tz.observances {
+		onsets, err := obs.onsets(horizon)
+		if err != nil {
+			return nil, fmt.Errorf("VTIMEZONE %s: %w", tz.tzid, err)
+		}
+		zones = append(zones, obs)
+		for _, onset := range onsets {
+			transitions = append(transitions, transition{when: onset.Unix(), zone: len(zones) - 1})
+		}
+	}
+
+	sort.Slice(transitions, func(i, j int) bool {
+		return transitions[i].when < transitions[j].when
+	})
+
+	// Encode as TZif version 2 with an empty 32-bit section
+	var abbrevs bytes.Buffer
+	abbrevIndex := make([]int, len(zones))
+	for i, zone := range zones {
+		abbrevIndex[i] = abbrevs.Len()
+		abbrevs.WriteString(zone.abbreviation())
+		abbrevs.WriteByte(0)
+	}
+
+	var buf bytes.Buffer
+	header := func(timeCount, zoneCount, charCount int) {
+		buf.WriteString("TZif2")
+		buf.Write(make([]byte, 15))
+		for _, n := range []int{0, 0, 0, timeCount, zoneCount, charCount} {
+			binary.Write(&buf, binary.BigEndian, uint32(n))
+		}
+	}
+	header(0, 0, 0)
+	header(len(transitions), len(zones), abbrevs.Len())
+	for _, tr := range transitions {
+		binary.Write(&buf, binary.BigEndian, tr.when)
+	}
+	for _, tr := range transitions {
+		buf.WriteByte(byte(tr.zone))
+	}
+	for i, zone := range zones {
+		binary.Write(&buf, binary.BigEndian, int32(zone.offsetTo))
+		if zone.daylight {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+		buf.WriteByte(byte(abbrevIndex[i]))
+	}
+	buf.Write(abbrevs.Bytes())
+
+	return time.LoadLocationFromTZData(tz.tzid, buf.Bytes())
+}
+
+// parseProperty handles a property of a STANDARD or DAYLIGHT sub-component
+func (obs *observance) parseProperty(prop property) {
+	switch prop.name {
+	case "DTSTART":
+		obs.dtstart = prop.value
+	case "TZOFFSETFROM":
+		obs.offsetFrom, _ = parseUTCOffset(prop.value)
+	case "TZOFFSETTO":
+		obs.offsetTo, _ = parseUTCOffset(prop.value)
+	case "TZNAME":
+		obs.name = prop.value
+	case "RRULE":
+		obs.rrule = prop.value
+	case "RDATE":
+		obs.rdates = append(obs.rdates, strings.Split(prop.value, ",")...)
+	}
+}
+
+// onsets returns the UTC instants at which the observance takes effect
+func (obs *observance) onsets(horizon time.Time) ([]time.Time, error) {
+	// Onsets are expanded as wall clock times in the TZOFFSETFROM offset
+	start, err := time.ParseInLocation("20060102T150405", obs.dtstart, time.UTC)
+	if err != nil {
+		return nil, fmt.Errorf("invalid observance DTSTART %q", obs.dtstart)
+	}
+
+	walls := []time.Time{start}
+	if obs.rrule != "" {
+		rule, err := ParseRecurrenceRule(obs.rrule, time.UTC)
+		if err != nil {
+			return nil, fmt.Errorf("invalid observance RRULE: %w", err)
+		}
+		if !rule.Until.IsZero() {
+			// UNTIL is in UTC; shift it onto the wall clock used for expansion
+			rule.Until = rule.Until.Add(time.Duration(obs.offsetFrom) * time.Second)
+		}
+		walls = rule.Between(start, time.Time{}, horizon)
+	}
+	for _, rdate := range obs.rdates {
+		if t, err := time.ParseInLocation("20060102T150405", rdate, time.UTC); err == nil {
+			walls = append(walls, t)
+		}
+	}
+
+	onsets := make([]time.Time, 0, len(walls))
+	for _, wall := range walls {
+		onsets = append(onsets, wall.Add(-time.Duration(obs.offsetFrom)*time.Second))
+	}
+	return onsets, nil
+}
+
+func (obs *observance) abbreviation() string {
+	if obs.name != "" {
+		return obs.name
+	}
+	offset := obs.offsetTo
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+	return fmt.Sprintf("%s%02d%02d", sign, offset/3600, offset%3600/60)
+}
+
+// parseUTCOffset parses a UTC-OFFSET value such as +0100, -0530 or +013045
+func parseUTCOffset(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if len(value) != 5 && len(value) != 7 {
+		return 0, fmt.Errorf("invalid UTC offset %q", value)
+	}
+
+	sign := 1
+	switch value[0] {
+	case '+':
+	case '-':
+		sign = -1
+	default:
+		return 0, fmt.Errorf("invalid UTC offset %q", value)
+	}
+
+	hours, err1 := strconv.Atoi(value[1:3])
+	minutes, err2 := strconv.Atoi(value[3:5])
+	seconds := 0
+	var err3 error
+	if len(value) == 7 {
+		seconds, err3 = strconv.Atoi(value[5:7])
+	}
+	if err1 != nil || err2 != nil || err3 != nil {
+		return 0, fmt.Errorf("invalid UTC offset %q", value)
+	}
+
+	return sign * (hours*3600 + minutes*60 + seconds), nil
+}
+
+// windowsZones maps the Windows time zone names used by Outlook and Exchange
+// to IANA zones, following the CLDR windowsZones territory "001" mapping
+var windowsZones = map[string]string{
+	"Dateline Standard Time":          "Etc/GMT+12",
+	"UTC-11":                          "Etc/GMT+11",
+	"Aleutian Standard Time":          "America/Adak",
+	"Hawaiian Standard Time":          "Pacific/Honolulu",
+	"Marquesas Standard Time":         "Pacific/Marquesas",
+	"Alaskan Standard Time":           "America/Anchorage",
+	"UTC-09":                          "Etc/GMT+9",
+	"Pacific Standard Time (Mexico)":  "America/Tijuana",
+	"UTC-08":                          "Etc/GMT+8",
+	"Pacific Standard Time":           "America/Los_Angeles",
+	"US Mountain Standard Time":       "America/Phoenix",
+	"Mountain Standard Time (Mexico)": "America/Mazatlan",
+	"Mountain Standard Time":          "America/Denver",
+	"Yukon Standard Time":             "America/Whitehorse",
+	"Central America Standard Time":   "America/Guatemala",
+	"Central Standard Time":           "America/Chicago",
+	"Easter Island Standard Time":     "Pacific/Easter",
+	"Central Standard Time (Mexico)":  "America/Mexico_City",
+	"Canada Central Standard Time":    "America/Regina",
+	"SA Pacific Standard Time":        "America/Bogota",
+	"Eastern Standard Time (Mexico)":  "America/Cancun",
+	"Eastern Standard Time":           "America/New_York",
+	"Haiti Standard Time":             "America/Port-au-Prince",
+	"Cuba Standard Time":              "America/Havana",
+	"US Eastern Standard Time":        "America/Indianapolis",
+	"Turks And Caicos Standard Time":  "America/Grand_Turk",
+	"Paraguay Standard Time":          "America/Asuncion",
+	"Atlantic Standard Time":          "America/Halifax",
+	"Venezuela Standard Time":         "America/Caracas",
+	"Central Brazilian Standard Time": "America/Cuiaba",
+	"SA Western Standard Time":        "America/La_Paz",
+	"Pacific SA Standard Time":        "America/Santiago",
+	"Newfoundland Standard Time":      "America/St_Johns",
+	"Tocantins Standard Time":         "America/Araguaina",
+	"E. South America Standard Time":  "America/Sao_Paulo",
+	"SA Eastern Standard Time":        "America/Cayenne",
+	"Argentina Standard Time":         "America/Buenos_Aires",
+	"Greenland Standard Time":         "America/Godthab",
+	"Montevideo Standard Time":        "America/Montevideo",
+	"Magallanes Standard Time":        "America/Punta_Arenas",
+	"Saint Pierre Standard Time":      "America/Miquelon",
+	"Bahia Standard Time":             "America/Bahia",
+	"UTC-02":                          "Etc/GMT+2",
+	"Azores Standard Time":            "Atlantic/Azores",
+	"Cape Verde Standard Time":        "Atlantic/Cape_Verde",
+	"UTC":                             "Etc/UTC",
+	"GMT Standard Time":               "Europe/London",
+	"Greenwich Standard Time":         "Atlantic/Reykjavik",
+	"Sao Tome Standard Time":          "Africa/Sao_Tome",
+	"Morocco Standard Time":           "Africa/Casablanca",
+	"W. Europe Standard Time":         "Europe/Berlin",
+	"Central Europe Standard Time":    "Europe/Budapest",
+	"Romance Standard Time":           "Europe/Paris",
+	"Central European Standard Time":  "Europe/Warsaw",
+	"W. Central Africa Standard Time": "Africa/Lagos",
+	"Jordan Standard Time":            "Asia/Amman",
+	"GTB Standard Time":               "Europe/Bucharest",
+	"Middle East Standard Time":       "Asia/Beirut",
+	"Egypt Standard Time":             "Africa/Cairo",
+	"E. Europe Standard Time":         "Europe/Chisinau",
+	"Syria Standard Time":             "Asia/Damascus",
+	"West Bank Standard Time":         "Asia/Hebron",
+	"South Africa Standard Time":      "Africa/Johannesburg",
+	"FLE Standard Time":               "Europe/Kiev",
+	"Israel Standard Time":            "Asia/Jerusalem",
+	"South Sudan Standard Time":       "Africa/Juba",
+	"Kaliningrad Standard Time":       "Europe/Kaliningrad",
+	"Sudan Standard Time":             "Africa/Khartoum",
+	"Libya Standard Time":             "Africa/Tripoli",
+	"Namibia Standard Time":           "Africa/Windhoek",
+	"Arabic Standard Time":            "Asia/Baghdad",
+	"Turkey Standard Time":            "Europe/Istanbul",
+	"Arab Standard Time":              "Asia/Riyadh",
+	"Belarus Standard Time":           "Europe/Minsk",
+	"Russian Standard Time":           "Europe/Moscow",
+	"E. Africa Standard Time":         "Africa/Nairobi",
+	"Volgograd Standard Time":         "Europe/Volgograd",
+	"Iran Standard Time":              "Asia/Tehran",
+	"Arabian Standard Time":           "Asia/Dubai",
+	"Astrakhan Standard Time":         "Europe/Astrakhan",
+	"Azerbaijan Standard Time":        "Asia/Baku",
+	"Russia Time Zone 3":              "Europe/Samara",
+	"Mauritius Standard Time":         "Indian/Mauritius",
+	"Saratov Standard Time":           "Europe/Saratov",
+	"Georgian Standard Time":          "Asia/Tbilisi",
+	"Caucasus Standard Time":          "Asia/Yerevan",
+	"Afghanistan Standard Time":       "Asia/Kabul",
+	"West Asia Standard Time":         "Asia/Tashkent",
+	"Ekaterinburg Standard Time":      "Asia/Yekaterinburg",
+	"Pakistan Standard Time":          "Asia/Karachi",
+	"Qyzylorda Standard Time":         "Asia/Qyzylorda",
+	"India Standard Time":             "Asia/Calcutta",
+	"Sri Lanka Standard Time":         "Asia/Colombo",
+	"Nepal Standard Time":             "Asia/Katmandu",
+	"Central Asia Standard Time":      "Asia/Almaty",
+	"Bangladesh Standard Time":        "Asia/Dhaka",
+	"Omsk Standard Time":              "Asia/Omsk",
+	"Myanmar Standard Time":           "Asia/Rangoon",
+	"SE Asia Standard Time":           "Asia/Bangkok",
+	"Altai Standard Time":             "Asia/Barnaul",
+	"W. Mongolia Standard Time":       "Asia/Hovd",
+	"North Asia Standard Time":        "Asia/Krasnoyarsk",
+	"N. Central Asia Standard Time":   "Asia/Novosibirsk",
+	"Tomsk Standard Time":             "Asia/Tomsk",
+	"China Standard Time":             "Asia/Shanghai",
+	"North Asia East Standard Time":   "Asia/Irkutsk",
+	"Singapore Standard Time":         "Asia/Singapore",
+	"W. Australia Standard Time":      "Australia/Perth",
+	"Taipei Standard Time":            "Asia/Taipei",
+	"Ulaanbaatar Standard Time":       "Asia/Ulaanbaatar",
+	"Aus Central W. Standard Time":    "Australia/Eucla",
+	"Transbaikal Standard Time":       "Asia/Chita",
+	"Tokyo Standard Time":             "Asia/Tokyo",
+	"North Korea Standard Time":       "Asia/Pyongyang",
+	"Korea Standard Time":             "Asia/Seoul",
+	"Yakutsk Standard Time":           "Asia/Yakutsk",
+	"Cen. Australia Standard Time":    "Australia/Adelaide",
+	"AUS Central Standard Time":       "Australia/Darwin",
+	"E. Australia Standard Time":      "Australia/Brisbane",
+	"AUS Eastern Standard Time":       "Australia/Sydney",
+	"West Pacific Standard Time":      "Pacific/Port_Moresby",
+	"Tasmania Standard Time":          "Australia/Hobart",
+	"Vladivostok Standard Time":       "Asia/Vladivostok",
+	"Lord Howe Standard Time":         "Australia/Lord_Howe",
+	"Bougainville Standard Time":      "Pacific/Bougainville",
+	"Russia Time Zone 10":             "Asia/Srednekolymsk",
+	"Magadan Standard Time":           "Asia/Magadan",
+	"Norfolk Standard Time":           "Pacific/Norfolk",
+	"Sakhalin Standard Time":          "Asia/Sakhalin",
+	"Central Pacific Standard Time":   "Pacific/Guadalcanal",
+	"Russia Time Zone 11":             "Asia/Kamchatka",
+	"New Zealand Standard Time":       "Pacific/Auckland",
+	"UTC+12":                          "Etc/GMT-12",
+	"Fiji Standard Time":              "Pacific/Fiji",
+	"Chatham Islands Standard Time":   "Pacific/Chatham",
+	"UTC+13":                          "Etc/GMT-13",
+	"Tonga Standard Time":             "Pacific/Tongatapu",
+	"Samoa Standard Time":             "Pacific/Apia",
+	"Line Islands Standard Time":      "Pacific/Kiritimati",
+}
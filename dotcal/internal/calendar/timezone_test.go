@@ -0,0 +1,232 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLookupZone(t *testing.T) {
+	tests := []struct {
+		tzid     string
+		expected string
+	}{
+		{"Europe/Berlin", "Europe/Berlin"},
+		{"Mountain Standard Time", "America/Denver"},
+		{"W. Europe Standard Time", "Europe/Berlin"},
+		{"/mozilla.org/20050126_1/America/New_York", "America/New_York"},
+		{"Not A Zone", ""},
+		{"", ""},
+	}
+
+	for _, tc := range tests {
+		loc := LookupZone(tc.tzid)
+		if tc.expected == "" {
+			if loc != nil {
+				t.Errorf("Expected no zone for %q, got %v", tc.tzid, loc)
+			}
+			continue
+		}
+		if loc == nil || loc.String() != tc.expected {
+			t.Errorf("Expected %s for %q, got %v", tc.expected, tc.tzid, loc)
+		}
+	}
+}
+
+func TestParseUTCOffset(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected int
+		wantErr  bool
+	}{
+		{"+0100", 3600, false},
+		{"-0530", -(5*3600 + 30*60), false},
+		{"+013045", 3600 + 30*60 + 45, false},
+		{"0100", 0, true},
+		{"+1", 0, true},
+	}
+
+	for _, tc := range tests {
+		got, err := parseUTCOffset(tc.value)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("Expected error for %q", tc.value)
+			}
+			continue
+		}
+		if err != nil || got != tc.expected {
+			t.Errorf("parseUTCOffset(%q) = %d, %v; want %d", tc.value, got, err, tc.expected)
+		}
+	}
+}
+
+func TestVTimezoneLocation(t *testing.T) {
+	zone := &vtimezone{
+		tzid: "Custom Central European",
+		observances: []*observance{
+			{
+				name:       "CEST",
+				daylight:   true,
+				dtstart:    "19700329T020000",
+				offsetFrom: 3600,
+				offsetTo:   7200,
+				rrule:      "FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU",
+			},
+			{
+				name:       "CET",
+				dtstart:    "19701025T030000",
+				offsetFrom: 7200,
+				offsetTo:   3600,
+				rrule:      "FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU",
+			},
+		},
+	}
+
+	loc, err := zone.location()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		when   time.Time
+		name   string
+		offset int
+	}{
+		{time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC), "CET", 3600},
+		{time.Date(2025, 3, 30, 0, 59, 0, 0, time.UTC), "CET", 3600},
+		{time.Date(2025, 3, 30, 1, 0, 0, 0, time.UTC), "CEST", 7200},
+		{time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC), "CEST", 7200},
+		{time.Date(2025, 10, 26, 1, 0, 0, 0, time.UTC), "CET", 3600},
+	}
+	for _, tc := range tests {
+		name, offset := tc.when.In(loc).Zone()
+		if name != tc.name || offset != tc.offset {
+			t.Errorf("At %v: expected %s %d, got %s %d", tc.when, tc.name, tc.offset, name, offset)
+		}
+	}
+}
+
+func TestParseTimezones(t *testing.T) {
+	t.Run("IANA TZID", func(t *testing.T) {
+		input := `BEGIN:VCALENDAR
+BEGIN:VEVENT
+DTSTART;TZID=Europe/Berlin:20250310T090000
+DTEND;TZID=Europe/Berlin:20250310T100000
+END:VEVENT
+END:VCALENDAR`
+
+		events, err := NewParser(time.UTC).Parse([]byte(input))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(events) != 1 {
+			t.Fatalf("Expected 1 event, got %d", len(events))
+		}
+		expected := time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)
+		if !events[0].Start.Equal(expected) {
+			t.Errorf("Expected start %v, got %v", expected, events[0].Start)
+		}
+		if events[0].Start.Location() != time.UTC {
+			t.Errorf("Expected start in parser timezone, got %v", events[0].Start.Location())
+		}
+	})
+
+	t.Run("quoted Windows TZID", func(t *testing.T) {
+		input := `BEGIN:VCALENDAR
+BEGIN:VEVENT
+DTSTART;TZID="Mountain Standard Time":20250310T090000
+END:VEVENT
+END:VCALENDAR`
+
+		events, err := NewParser(time.UTC).Parse([]byte(input))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		expected := time.Date(2025, 3, 10, 15, 0, 0, 0, time.UTC) // MDT is UTC-6
+		if len(events) != 1 || !events[0].Start.Equal(expected) {
+			t.Errorf("Expected start %v, got %v", expected, events)
+		}
+	})
+
+	t.Run("custom VTIMEZONE", func(t *testing.T) {
+		input := `BEGIN:VCALENDAR
+BEGIN:VTIMEZONE
+TZID:Office Time
+BEGIN:STANDARD
+DTSTART:16010101T020000
+TZOFFSETFROM:-0600
+TZOFFSETTO:-0700
+RRULE:FREQ=YEARLY;BYDAY=1SU;BYMONTH=11
+END:STANDARD
+BEGIN:DAYLIGHT
+DTSTART:16010101T020000
+TZOFFSETFROM:-0700
+TZOFFSETTO:-0600
+RRULE:FREQ=YEARLY;BYDAY=2SU;BYMONTH=3
+END:DAYLIGHT
+END:VTIMEZONE
+BEGIN:VEVENT
+DTSTART;TZID=Office Time:20250120T090000
+END:VEVENT
+BEGIN:VEVENT
+DTSTART;TZID=Office Time:20250720T090000
+END:VEVENT
+END:VCALENDAR`
+
+		events, err := NewParser(time.UTC).Parse([]byte(input))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(events) != 2 {
+			t.Fatalf("Expected 2 events, got %d", len(events))
+		}
+		winter := time.Date(2025, 1, 20, 16, 0, 0, 0, time.UTC)
+		summer := time.Date(2025, 7, 20, 15, 0, 0, 0, time.UTC)
+		if !events[0].Start.Equal(winter) {
+			t.Errorf("Expected winter start %v, got %v", winter, events[0].Start)
+		}
+		if !events[1].Start.Equal(summer) {
+			t.Errorf("Expected summer start %v, got %v", summer, events[1].Start)
+		}
+	})
+
+	t.Run("feed timezone applies to floating times", func(t *testing.T) {
+		input := `BEGIN:VCALENDAR
+BEGIN:VEVENT
+DTSTART:20250310T090000
+END:VEVENT
+END:VCALENDAR`
+
+		tokyo, err := time.LoadLocation("Asia/Tokyo")
+		if err != nil {
+			t.Skip("timezone data unavailable")
+		}
+		events, _, err := NewParser(time.UTC).ParseFeed(Feed{TimeZone: tokyo}, []byte(input))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		expected := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+		if len(events) != 1 || !events[0].Start.Equal(expected) {
+			t.Errorf("Expected start %v, got %v", expected, events)
+		}
+	})
+
+	t.Run("UTC times ignore parser timezone", func(t *testing.T) {
+		nyc, err := time.LoadLocation("America/New_York")
+		if err != nil {
+			t.Skip("timezone data unavailable")
+		}
+		input := `BEGIN:VCALENDAR
+BEGIN:VEVENT
+DTSTART:20250310T150000Z
+END:VEVENT
+END:VCALENDAR`
+
+		events, err := NewParser(nyc).Parse([]byte(input))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(events) != 1 || events[0].Start.Hour() != 11 {
+			t.Errorf("Expected 11 AM New York time, got %v", events)
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package calendar
+
+import (
+	"time"
+)
+
+// defaultTodoLength is the time blocked before a to-do's due time when it
+// has no start of its own
+const defaultTodoLength = 30 * time.Minute
+
+// parseTodoProperty handles a property of a VTODO. Apart from DUE, to-dos
+// share their properties with events.
+func (st *parseState) parseTodoProperty(prop property, todo *vevent) error {
+	switch prop.name {
+	case "DUE":
+		var err error
+		todo.due, todo.dueDate, err = st.parseDateTime(prop)
+		return err
+	case "DTEND":
+		// Not part of a VTODO; ignored rather than mistaken for DUE
+		return nil
+	default:
+		return st.parseEventProperty(prop, todo)
+	}
+}
+
+// scheduleTodo turns a VTODO into a busy block ending when it is due and
+// reports whether it should be kept. To-dos without a due time, and those
+// already completed, do not block time.
+func (v *vevent) scheduleTodo() bool {
+	if v.status == "COMPLETED" {
+		return false
+	}
+
+	due := v.due
+	if due.IsZero() && v.duration != nil && !v.Start.IsZero() {
+		due = v.duration.addTo(v.Start)
+	}
+	if due.IsZero() {
+		return false
+	}
+
+	// A date-only due date blocks that whole day
+	if v.dueDate {
+		v.Start, v.End, v.AllDay = due, due.AddDate(0, 0, 1), true
+		return true
+	}
+
+	if v.Start.IsZero() || !v.Start.Before(due) {
+		v.Start = due.Add(-defaultTodoLength)
+	}
+	v.End, v.AllDay = due, false
+	return true
+}
@@ -0,0 +1,68 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTodos(t *testing.T) {
+	parser := NewParser(time.UTC)
+	parser.SetIncludeTodos(true)
+
+	parse := func(t *testing.T, properties string) []Event {
+		t.Helper()
+		input := "BEGIN:VCALENDAR\nBEGIN:VTODO\n" + properties + "\nEND:VTODO\nEND:VCALENDAR"
+		events, err := parser.Parse([]byte(input))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return events
+	}
+
+	t.Run("start to due", func(t *testing.T) {
+		events := parse(t, "DTSTART:20250310T090000Z\nDUE:20250310T110000Z")
+		if len(events) != 1 {
+			t.Fatalf("Expected 1 event, got %d", len(events))
+		}
+		if !events[0].Start.Equal(time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)) ||
+			!events[0].End.Equal(time.Date(2025, 3, 10, 11, 0, 0, 0, time.UTC)) {
+			t.Errorf("Expected 9-11 AM block, got %v - %v", events[0].Start, events[0].End)
+		}
+	})
+
+	t.Run("due without start", func(t *testing.T) {
+		events := parse(t, "DUE:20250310T110000Z")
+		if len(events) != 1 {
+			t.Fatalf("Expected 1 event, got %d", len(events))
+		}
+		if !events[0].Start.Equal(time.Date(2025, 3, 10, 10, 30, 0, 0, time.UTC)) {
+			t.Errorf("Expected default block before due time, got %v", events[0].Start)
+		}
+	})
+
+	t.Run("start and duration", func(t *testing.T) {
+		events := parse(t, "DTSTART:20250310T090000Z\nDURATION:PT45M")
+		if len(events) != 1 || !events[0].End.Equal(time.Date(2025, 3, 10, 9, 45, 0, 0, time.UTC)) {
+			t.Errorf("Expected block ending at 9:45 AM, got %v", events)
+		}
+	})
+
+	t.Run("date-only due blocks the day", func(t *testing.T) {
+		events := parse(t, "DUE;VALUE=DATE:20250312")
+		if len(events) != 1 || !events[0].AllDay || events[0].Start.Day() != 12 || events[0].End.Day() != 13 {
+			t.Errorf("Expected all-day block on March 12, got %v", events)
+		}
+	})
+
+	t.Run("ignored to-dos", func(t *testing.T) {
+		for _, properties := range []string{
+			"SUMMARY:No due date",
+			"DUE:20250310T110000Z\nSTATUS:COMPLETED",
+			"DUE:20250310T110000Z\nSTATUS:CANCELLED",
+		} {
+			if events := parse(t, properties); len(events) != 0 {
+				t.Errorf("Expected %q to be ignored, got %v", properties, events)
+			}
+		}
+	})
+}
@@ -0,0 +1,207 @@
+package calendar
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// TLSConfig holds a feed's TLS settings
+type TLSConfig struct {
+	// Client certificate and key for servers that require mutual TLS, PEM
+	// encoded
+	CertFile string
+	KeyFile  string
+	// PinSHA256 is the hex SHA-256 of the server's certificate, accepted
+	// instead of verifying it against the trusted CAs. Local hosts only.
+	PinSHA256 string
+	// InsecureSkipVerify accepts any server certificate. Local hosts only,
+	// for testing.
+	InsecureSkipVerify bool
+	// Host names a host on the network that may also be pinned or left
+	// unverified, such as a NAS; it must be the feed's host
+	Host string
+}
+
+// SetProxy sets the proxy for URL feeds, such as http://proxy.example.com:3128.
+// An empty proxyURL uses the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+// environment variables.
+func (f *Fetcher) SetProxy(proxyURL string) error {
+	if proxyURL == "" {
+		f.proxy = http.ProxyFromEnvironment
+	} else {
+		u, err := url.Parse(proxyURL)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("invalid proxy URL %q", RedactURL(proxyURL))
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return fmt.Errorf("unsupported proxy scheme %q", u.Scheme)
+		}
+		f.proxy = http.ProxyURL(u)
+	}
+	f.resetClients()
+	return nil
+}
+
+// AddCAFile trusts the certificates in a PEM file, such as an internal CA,
+// in addition to the system's
+func (f *Fetcher) AddCAFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read CA file: %w", err)
+	}
+	if f.rootCAs == nil {
+		if f.rootCAs, err = x509.SystemCertPool(); err != nil {
+			f.rootCAs = x509.NewCertPool()
+		}
+	}
+	if !f.rootCAs.AppendCertsFromPEM(data) {
+		return fmt.Errorf("no certificates found in CA file %s", path)
+	}
+	f.resetClients()
+	return nil
+}
+
+// resetClients rebuilds the HTTP clients after a change of settings
+func (f *Fetcher) resetClients() {
+	f.client = &http.Client{Transport: f.newTransport(&tls.Config{})}
+	f.mu.Lock()
+	f.clients = nil
+	f.mu.Unlock()
+}
+
+// newTransport returns a transport using the fetcher's proxy and CAs
+func (f *Fetcher) newTransport(tlsConfig *tls.Config) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = f.proxy
+	tlsConfig.RootCAs = f.rootCAs
+	transport.TLSClientConfig = tlsConfig
+	return transport
+}
+
+// clientFor returns the HTTP client for a feed. Feeds with TLS settings of
+// their own get a client of their own, and feeds sending headers of their
+// own a client that keeps them from other hosts.
+func (f *Fetcher) clientFor(feed Feed) (*http.Client, error) {
+	client, err := f.tlsClientFor(feed)
+	if err != nil || feed.Auth == nil || len(feed.Auth.Headers) == 0 {
+		return client, err
+	}
+
+	authed := *client
+	authed.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if client.CheckRedirect != nil {
+			if err := client.CheckRedirect(req, via); err != nil {
+				return err
+			}
+		} else if len(via) >= 10 {
+			return fmt.Errorf("stopped after 10 redirects")
+		}
+		feed.Auth.redirect(req, via)
+		return nil
+	}
+	return &authed, nil
+}
+
+// tlsClientFor returns the client for a feed's TLS settings
+func (f *Fetcher) tlsClientFor(feed Feed) (*http.Client, error) {
+	if feed.TLS == nil {
+		return f.client, nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if client, ok := f.clients[feed.ID]; ok {
+		return client, nil
+	}
+
+	host := feedHost(feed)
+	tlsConfig, err := feed.TLS.config(host)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Transport: f.newTransport(tlsConfig)}
+	if tlsConfig.InsecureSkipVerify {
+		// The relaxed checks must not follow a redirect off the local host
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if !strings.EqualFold(req.URL.Hostname(), host) {
+				return fmt.Errorf("redirect to %s is not allowed for a feed without certificate verification", req.URL.Hostname())
+			}
+			if len(via) >= 10 {
+				return fmt.Errorf("stopped after 10 redirects")
+			}
+			return nil
+		}
+	}
+
+	if f.clients == nil {
+		f.clients = make(map[string]*http.Client)
+	}
+	f.clients[feed.ID] = client
+	return client, nil
+}
+
+// config builds the TLS configuration for a feed on host
+func (c *TLSConfig) config(host string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if c.PinSHA256 == "" && !c.InsecureSkipVerify {
+		return tlsConfig, nil
+	}
+	if !c.AllowsRelaxed(host) {
+		return nil, fmt.Errorf("certificate pinning and skipping verification are only allowed for the local machine or a host named in the TLS settings, not %s", host)
+	}
+	// Verification against CAs is replaced by the pin, if any
+	tlsConfig.InsecureSkipVerify = true
+	if c.PinSHA256 != "" {
+		pin := strings.ToLower(strings.ReplaceAll(c.PinSHA256, ":", ""))
+		tlsConfig.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return fmt.Errorf("%s presented no certificate", host)
+			}
+			sum := sha256.Sum256(rawCerts[0])
+			if hex.EncodeToString(sum[:]) != pin {
+				return fmt.Errorf("certificate of %s does not match the pinned fingerprint", host)
+			}
+			return nil
+		}
+	}
+	return tlsConfig, nil
+}
+
+// AllowsRelaxed reports whether the certificate of host may be pinned or
+// left unverified: only the local machine's, or that of the host named in
+// the settings
+func (c *TLSConfig) AllowsRelaxed(host string) bool {
+	if IsLocalHost(host) {
+		return true
+	}
+	named := strings.TrimSuffix(c.Host, ".")
+	return named != "" && strings.EqualFold(named, strings.TrimSuffix(host, "."))
+}
+
+// IsLocalHost reports whether host is the local machine
+func IsLocalHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
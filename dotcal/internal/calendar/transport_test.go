@@ -0,0 +1,198 @@
+package calendar
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writePEM writes a PEM block to a file in dir and returns its path
+func writePEM(t *testing.T, dir, name, blockType string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data}), 0600); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestFetchTLS(t *testing.T) {
+	testData := "BEGIN:VCALENDAR\nEND:VCALENDAR"
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testData))
+	})
+	server := httptest.NewTLSServer(handler)
+	defer server.Close()
+	dir := t.TempDir()
+	feed := Feed{ID: "internal", Source: server.URL, IsURL: true}
+
+	t.Run("untrusted certificate", func(t *testing.T) {
+		if _, err := newTestFetcher().Fetch(feed); err == nil {
+			t.Error("Expected error for a certificate signed by an unknown CA")
+		}
+	})
+
+	t.Run("CA file", func(t *testing.T) {
+		fetcher := newTestFetcher()
+		if err := fetcher.AddCAFile(writePEM(t, dir, "ca.pem", "CERTIFICATE", server.Certificate().Raw)); err != nil {
+			t.Fatalf("Failed to add CA file: %v", err)
+		}
+		if _, err := fetcher.Fetch(feed); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		empty := filepath.Join(dir, "empty.pem")
+		os.WriteFile(empty, []byte("no certificates here"), 0644)
+		if err := fetcher.AddCAFile(empty); err == nil {
+			t.Error("Expected error for a file without certificates")
+		}
+	})
+
+	t.Run("pinned certificate", func(t *testing.T) {
+		sum := sha256.Sum256(server.Certificate().Raw)
+		pinned := feed
+		pinned.TLS = &TLSConfig{PinSHA256: hex.EncodeToString(sum[:])}
+		if _, err := newTestFetcher().Fetch(pinned); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		pinned.ID = "wrong pin"
+		pinned.TLS = &TLSConfig{PinSHA256: hex.EncodeToString(make([]byte, 32))}
+		if _, err := newTestFetcher().Fetch(pinned); err == nil {
+			t.Error("Expected error for a certificate that does not match the pin")
+		}
+	})
+
+	t.Run("skip verification", func(t *testing.T) {
+		insecure := feed
+		insecure.TLS = &TLSConfig{InsecureSkipVerify: true}
+		if _, err := newTestFetcher().Fetch(insecure); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		remote := Feed{ID: "remote", Source: "https://calendar.example.com/a.ics", IsURL: true, TLS: insecure.TLS}
+		if _, err := newTestFetcher().Fetch(remote); err == nil {
+			t.Error("Expected skipping verification to be refused for a remote host")
+		}
+	})
+
+	t.Run("client certificate", func(t *testing.T) {
+		mtls := httptest.NewUnstartedServer(handler)
+		mtls.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+		mtls.StartTLS()
+		defer mtls.Close()
+
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("Failed to generate key: %v", err)
+		}
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "dotcal"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		if err != nil {
+			t.Fatalf("Failed to create certificate: %v", err)
+		}
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatalf("Failed to marshal key: %v", err)
+		}
+
+		fetcher := newTestFetcher()
+		fetcher.AddCAFile(writePEM(t, dir, "mtls-ca.pem", "CERTIFICATE", mtls.Certificate().Raw))
+		mtlsFeed := Feed{ID: "mtls", Source: mtls.URL, IsURL: true}
+		if _, err := fetcher.Fetch(mtlsFeed); err == nil {
+			t.Error("Expected error without a client certificate")
+		}
+
+		mtlsFeed.TLS = &TLSConfig{
+			CertFile: writePEM(t, dir, "client.pem", "CERTIFICATE", der),
+			KeyFile:  writePEM(t, dir, "client-key.pem", "EC PRIVATE KEY", keyDER),
+		}
+		if _, err := fetcher.Fetch(mtlsFeed); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+}
+
+func TestFetchProxy(t *testing.T) {
+	var requested string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.String()
+		w.Write([]byte("BEGIN:VCALENDAR\nEND:VCALENDAR"))
+	}))
+	defer proxy.Close()
+
+	fetcher := newTestFetcher()
+	if err := fetcher.SetProxy(proxy.URL); err != nil {
+		t.Fatalf("Failed to set proxy: %v", err)
+	}
+	if _, err := fetcher.Fetch(Feed{Source: "http://calendar.invalid/team.ics", IsURL: true}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if requested != "http://calendar.invalid/team.ics" {
+		t.Errorf("Expected the feed to be requested through the proxy, got %q", requested)
+	}
+
+	for _, invalid := range []string{"proxy.example.com", "ftp://proxy.example.com"} {
+		if err := fetcher.SetProxy(invalid); err == nil {
+			t.Errorf("Expected error for proxy %q", invalid)
+		}
+	}
+}
+
+func TestIsLocalHost(t *testing.T) {
+	tests := map[string]bool{
+		"localhost":          true,
+		"dav.localhost":      true,
+		"nas.local":          false,
+		"127.0.0.1":          true,
+		"::1":                true,
+		"192.168.1.20":       false,
+		"10.0.0.5":           false,
+		"calendar.local.com": false,
+		"example.com":        false,
+		"8.8.8.8":            false,
+	}
+	for host, expected := range tests {
+		if got := IsLocalHost(host); got != expected {
+			t.Errorf("Expected %v for %q, got %v", expected, host, got)
+		}
+	}
+}
+
+func TestTLSConfigAllowsRelaxed(t *testing.T) {
+	named := &TLSConfig{InsecureSkipVerify: true, Host: "NAS.local."}
+	tests := map[string]bool{
+		"localhost":    true,
+		"nas.local":    true,
+		"other.local":  false,
+		"192.168.1.20": false,
+	}
+	for host, expected := range tests {
+		if got := named.AllowsRelaxed(host); got != expected {
+			t.Errorf("Expected %v for %q, got %v", expected, host, got)
+		}
+	}
+
+	if _, err := (&TLSConfig{InsecureSkipVerify: true}).config("192.168.1.20"); err == nil {
+		t.Error("Expected error for an unverified LAN host that is not named")
+	}
+}
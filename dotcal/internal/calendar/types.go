@@ -8,9 +8,10 @@ import (
 type Status string
 
 const (
-	StatusAvailable Status = "available"
-	StatusBusy      Status = "busy"
-	StatusTentative Status = "tentative"
+	StatusAvailable   Status = "available"
+	StatusBusy        Status = "busy"
+	StatusTentative   Status = "tentative"
+	StatusOutOfOffice Status = "out-of-office"
 )
 
 // Event represents a calendar event
@@ -21,9 +22,16 @@ type Event struct {
 	Title       string
 	Description string
 	Location    string
+	AllDay      bool // Date-only event; End is the exclusive end date
+
+	// Recurrence identity, used to correlate overridden instances
+	UID          string
+	RecurrenceID time.Time // Original start of a recurring instance
+	Sequence     int
 }
 
-// TimeSlot represents a 30-minute slot in the schedule
+// TimeSlot represents a slot in the schedule, as long as the merger's slot
+// length unless a working range ends before it does
 type TimeSlot struct {
 	Start    time.Time
 	End      time.Time
@@ -31,14 +39,47 @@ type TimeSlot struct {
 	Original *Event // Reference to original event if not available
 }
 
+// FeedType selects the protocol used to read a feed
+type FeedType string
+
+const (
+	// FeedICS is a single iCalendar, jCal or xCal document
+	FeedICS FeedType = ""
+	// FeedCalDAV is a CalDAV calendar, calendar home or principal URL
+	FeedCalDAV FeedType = "caldav"
+)
+
 // Feed represents a calendar feed source
 type Feed struct {
 	ID       string
 	Source   string // URL or file path
 	IsURL    bool
+	Type     FeedType
+	Format   Format        // Detected from the content type or data when empty
+	Timeout  time.Duration // Overrides the fetcher's timeout when non-zero
+	Auth     *Auth         // Credentials for URL feeds, nil for none
+	TLS      *TLSConfig    // Client certificate and verification settings, nil for the defaults
 	TimeZone *time.Location
 }
 
+// FeedResult holds the events parsed from a single feed
+type FeedResult struct {
+	Feed        Feed
+	Events      []Event
+	ParseErrors []*ParseError // Malformed entries that were skipped, and warnings
+	Cache       CacheStatus   // Whether the cached copy of the feed was reused
+	Stale       bool          // Refreshing failed and the last good copy was used
+	FetchedAt   time.Time     // When the data was last fetched successfully
+	FetchErr    error         // Why the feed could not be refreshed when Stale
+	Err         error         // Why the feed could not be used at all (FetchAll only)
+}
+
+// StaleFeed identifies a feed shown from an out-of-date copy
+type StaleFeed struct {
+	ID        string
+	FetchedAt time.Time // When the copy was fetched
+}
+
 // Schedule represents a processed calendar schedule
 type Schedule struct {
 	TimeZone *time.Location
@@ -47,8 +88,13 @@ type Schedule struct {
 
 // WeekSchedule represents a full week of time slots
 type WeekSchedule struct {
-	Year     int
-	Week     int
-	TimeZone *time.Location
-	Days     map[time.Weekday][]TimeSlot
+	Year       int
+	Week       int
+	TimeZone   *time.Location
+	SlotLength time.Duration
+	Days       map[time.Weekday][]TimeSlot
+	AllDay     map[time.Weekday][]Event // All-day events noted without blocking slots
+
+	StaleFeeds   []StaleFeed // Feeds that could not be refreshed
+	MissingFeeds []string    // IDs of feeds that failed without a copy to fall back to
 }
@@ -0,0 +1,118 @@
+package calendar
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// xcalNode is a generic element of an xCal document
+type xcalNode struct {
+	XMLName  xml.Name
+	Children []xcalNode `xml:",any"`
+	Text     string     `xml:",chardata"`
+}
+
+// xcalToICS converts an xCal document into iCalendar content lines so it can
+// be read by the same parser as ICS feeds
+func xcalToICS(r io.Reader) ([]byte, error) {
+	var root xcalNode
+	if err := xml.NewDecoder(r).Decode(&root); err != nil {
+		return nil, err
+	}
+	if root.XMLName.Local != "icalendar" {
+		return nil, fmt.Errorf("unexpected root element %q", root.XMLName.Local)
+	}
+
+	w := &icalWriter{}
+	for _, component := range root.Children {
+		w.xcalComponent(component)
+	}
+	return w.buf.Bytes(), nil
+}
+
+// xcalComponent writes a component element with its properties and
+// sub-components
+func (w *icalWriter) xcalComponent(n xcalNode) {
+	name := strings.ToUpper(n.XMLName.Local)
+	w.line("BEGIN", nil, name)
+	for _, child := range n.Children {
+		switch child.XMLName.Local {
+		case "properties":
+			for _, prop := range child.Children {
+				w.xcalProperty(prop)
+			}
+		case "components":
+			for _, sub := range child.Children {
+				w.xcalComponent(sub)
+			}
+		}
+	}
+	w.line("END", nil, name)
+}
+
+// xcalProperty writes a property element; its children are an optional
+// parameters element followed by typed values
+func (w *icalWriter) xcalProperty(n xcalNode) {
+	params := make(map[string]string)
+	var values []string
+
+	for _, child := range n.Children {
+		if child.XMLName.Local == "parameters" {
+			for _, param := range child.Children {
+				var paramValues []string
+				for _, value := range param.Children {
+					paramValues = append(paramValues, value.Text)
+				}
+				params[param.XMLName.Local] = strings.Join(paramValues, ",")
+			}
+			continue
+		}
+		if child.XMLName.Local == "date" {
+			params["value"] = "DATE"
+		}
+		values = append(values, xcalValue(child))
+	}
+
+	w.line(n.XMLName.Local, params, strings.Join(values, ","))
+}
+
+// xcalValue converts a typed value element
+func xcalValue(n xcalNode) string {
+	switch n.XMLName.Local {
+	case "recur":
+		var parts []string
+		index := make(map[string]int)
+		for _, child := range n.Children {
+			key := strings.ToUpper(child.XMLName.Local)
+			value := strings.TrimSpace(child.Text)
+			if key == "UNTIL" {
+				value = icalValue("date-time", value)
+			}
+			// Repeated elements such as <byday> form one list
+			if i, ok := index[key]; ok {
+				parts[i] += "," + value
+				continue
+			}
+			index[key] = len(parts)
+			parts = append(parts, key+"="+value)
+		}
+		return strings.Join(parts, ";")
+	case "period":
+		var start, end string
+		for _, child := range n.Children {
+			switch child.XMLName.Local {
+			case "start":
+				start = strings.TrimSpace(child.Text)
+			case "end", "duration":
+				end = strings.TrimSpace(child.Text)
+			}
+		}
+		return icalValue("period", start+"/"+end)
+	case "text":
+		return icalValue("text", n.Text)
+	default:
+		return icalValue(n.XMLName.Local, strings.TrimSpace(n.Text))
+	}
+}
@@ -0,0 +1,102 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseXCal(t *testing.T) {
+	input := `<?xml version="1.0" encoding="utf-8"?>
+<icalendar xmlns="urn:ietf:params:xml:ns:icalendar-2.0">
+  <vcalendar>
+    <properties>
+      <version><text>2.0</text></version>
+    </properties>
+    <components>
+      <vevent>
+        <properties>
+          <uid><text>review@example.com</text></uid>
+          <summary><text>Review, part 1</text></summary>
+          <dtstart>
+            <parameters><tzid><text>Europe/Berlin</text></tzid></parameters>
+            <date-time>2025-03-10T14:00:00</date-time>
+          </dtstart>
+          <dtend>
+            <parameters><tzid><text>Europe/Berlin</text></tzid></parameters>
+            <date-time>2025-03-10T15:00:00</date-time>
+          </dtend>
+          <rrule>
+            <recur>
+              <freq>WEEKLY</freq>
+              <byday>MO</byday>
+              <byday>TH</byday>
+              <until>2025-03-14T00:00:00Z</until>
+            </recur>
+          </rrule>
+          <status><text>TENTATIVE</text></status>
+        </properties>
+      </vevent>
+      <vfreebusy>
+        <properties>
+          <freebusy>
+            <period>
+              <start>2025-03-12T09:00:00Z</start>
+              <end>2025-03-12T10:00:00Z</end>
+            </period>
+          </freebusy>
+        </properties>
+      </vfreebusy>
+    </components>
+  </vcalendar>
+</icalendar>`
+
+	events, err := NewParser(time.UTC).Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	expected := []struct {
+		title  string
+		start  time.Time
+		end    time.Time
+		status Status
+	}{
+		{"", time.Date(2025, 3, 12, 9, 0, 0, 0, time.UTC), time.Date(2025, 3, 12, 10, 0, 0, 0, time.UTC), StatusBusy},
+		{"Review, part 1", time.Date(2025, 3, 10, 14, 0, 0, 0, berlin), time.Date(2025, 3, 10, 15, 0, 0, 0, berlin), StatusTentative},
+		{"Review, part 1", time.Date(2025, 3, 13, 14, 0, 0, 0, berlin), time.Date(2025, 3, 13, 15, 0, 0, 0, berlin), StatusTentative},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, got %d: %v", len(expected), len(events), events)
+	}
+	for i, e := range expected {
+		got := events[i]
+		if got.Title != e.title || !got.Start.Equal(e.start) || !got.End.Equal(e.end) || got.Status != e.status {
+			t.Errorf("Event %d: expected %s %v-%v %s, got %s %v-%v %s",
+				i, e.title, e.start, e.end, e.status, got.Title, got.Start, got.End, got.Status)
+		}
+	}
+}
+
+func TestXCalToICS(t *testing.T) {
+	t.Run("date values", func(t *testing.T) {
+		input := `<icalendar><vcalendar><components><vevent><properties>
+			<dtstart><date>2025-03-14</date></dtstart>
+		</properties></vevent></components></vcalendar></icalendar>`
+		data, err := xcalToICS(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		expected := "DTSTART;VALUE=DATE:20250314\r\n"
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("Expected %q in %q", expected, data)
+		}
+	})
+
+	t.Run("wrong root element", func(t *testing.T) {
+		if _, err := xcalToICS(strings.NewReader("<html></html>")); err == nil {
+			t.Error("Expected error for non-xCal document")
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"text/template"
 	"time"
@@ -35,16 +36,28 @@ type NavigationData struct {
 // WeekTemplateData holds data for weekly view
 type WeekTemplateData struct {
 	TemplateData
-	Schedule  *calendar.WeekSchedule
-	TimeSlots []TimeSlotData
-	StartDate time.Time
-	EndDate   time.Time
+	Schedule   *calendar.WeekSchedule
+	Days       []DayColumnData // The schedule's working days, Monday first
+	TimeSlots  []TimeSlotData
+	AllDay     []DaySlotData // One entry per day column, nil when there are no all-day events
+	StaleSince string        // Oldest copy shown for feeds that could not be refreshed, empty when all are current
+	Missing    string        // Such as "2 calendars" for feeds that could not be fetched at all, empty when none
+	SlotLength string        // Such as "15-minute" or "1-hour", empty when unknown
+	StartDate  time.Time
+	EndDate    time.Time
+}
+
+// DayColumnData represents a day column of the weekly table
+type DayColumnData struct {
+	Weekday time.Weekday
+	Name    string
+	Date    time.Time
 }
 
 // TimeSlotData represents a single time slot
 type TimeSlotData struct {
 	Time     string
-	DaySlots []DaySlotData
+	DaySlots []DaySlotData // One entry per day column, empty for days not working at that time
 }
 
 // DaySlotData represents a slot for a specific day
@@ -100,7 +113,11 @@ func (g *Generator) loadTemplates() error {
 // GenerateWeekSchedule creates a markdown schedule for a week
 func (g *Generator) GenerateWeekSchedule(schedule *calendar.WeekSchedule) (string, error) {
 	startDate := calendar.FirstDayOfISOWeek(schedule.Year, schedule.Week, schedule.TimeZone)
-	endDate := startDate.AddDate(0, 0, 4) // Friday
+	days := g.buildDays(schedule, startDate)
+	endDate := startDate
+	if len(days) > 0 {
+		endDate = days[len(days)-1].Date
+	}
 
 	data := WeekTemplateData{
 		StartDate: startDate,
@@ -110,8 +127,13 @@ func (g *Generator) GenerateWeekSchedule(schedule *calendar.WeekSchedule) (strin
 			TimeZone:    schedule.TimeZone,
 			LastUpdated: time.Now().In(schedule.TimeZone).Format("2006-01-02 15:04 MST"),
 		},
-		Schedule:  schedule,
-		TimeSlots: g.buildTimeSlots(schedule),
+		Schedule:   schedule,
+		Days:       days,
+		TimeSlots:  g.buildTimeSlots(schedule, days),
+		AllDay:     g.buildAllDay(schedule, days),
+		StaleSince: g.staleSince(schedule),
+		Missing:    formatMissing(len(schedule.MissingFeeds)),
+		SlotLength: formatSlotLength(schedule.SlotLength),
 	}
 
 	var output strings.Builder
@@ -122,32 +144,143 @@ func (g *Generator) GenerateWeekSchedule(schedule *calendar.WeekSchedule) (strin
 	return output.String(), nil
 }
 
-// buildTimeSlots converts schedule slots into template data
-func (g *Generator) buildTimeSlots(schedule *calendar.WeekSchedule) []TimeSlotData {
-	var slots []TimeSlotData
-	daySlots := schedule.Days[time.Monday] // Use Monday's slots as reference
-
-	for i := range daySlots {
-		slot := daySlots[i]
-		timeStr := fmt.Sprintf("%s - %s",
-			slot.Start.Format("3:04 PM"),
-			slot.End.Format("3:04 PM"))
-
-		var daySlots []DaySlotData
-		for day := time.Monday; day <= time.Friday; day++ {
-			daySlot := schedule.Days[day][i]
-			daySlots = append(daySlots, g.buildDaySlot(daySlot))
+// buildDays lists the days the schedule has slots for, Monday first
+func (g *Generator) buildDays(schedule *calendar.WeekSchedule, weekStart time.Time) []DayColumnData {
+	var days []DayColumnData
+	for i := 0; i < 7; i++ {
+		date := weekStart.AddDate(0, 0, i)
+		if _, ok := schedule.Days[date.Weekday()]; !ok {
+			continue
+		}
+		days = append(days, DayColumnData{
+			Weekday: date.Weekday(),
+			Name:    date.Weekday().String(),
+			Date:    date,
+		})
+	}
+	return days
+}
+
+// slotTime is a slot's start and end time of day, used to line up the
+// slots of days with different hours
+type slotTime struct {
+	start, end time.Duration
+}
+
+func clockOf(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+}
+
+// buildTimeSlots converts schedule slots into template data, with a row
+// for every slot time any day has
+func (g *Generator) buildTimeSlots(schedule *calendar.WeekSchedule, days []DayColumnData) []TimeSlotData {
+	rows := make(map[slotTime][]DaySlotData)
+	labels := make(map[slotTime]string)
+	var times []slotTime
+
+	for col, day := range days {
+		for _, slot := range schedule.Days[day.Weekday] {
+			key := slotTime{start: clockOf(slot.Start), end: clockOf(slot.Start) + slot.End.Sub(slot.Start)}
+			if _, ok := rows[key]; !ok {
+				rows[key] = make([]DaySlotData, len(days))
+				labels[key] = g.slotLabel(slot, schedule.SlotLength)
+				times = append(times, key)
+			}
+			rows[key][col] = g.buildDaySlot(slot)
+		}
+	}
+
+	sort.Slice(times, func(i, j int) bool {
+		if times[i].start != times[j].start {
+			return times[i].start < times[j].start
 		}
+		return times[i].end < times[j].end
+	})
 
+	var slots []TimeSlotData
+	for _, key := range times {
 		slots = append(slots, TimeSlotData{
-			Time:     timeStr,
-			DaySlots: daySlots,
+			Time:     labels[key],
+			DaySlots: rows[key],
 		})
 	}
 
 	return slots
 }
 
+// slotLabel returns a slot's time range. Hourly slots leave out the
+// minutes of times on the hour.
+func (g *Generator) slotLabel(slot calendar.TimeSlot, length time.Duration) string {
+	format := func(t time.Time) string {
+		if length >= time.Hour && t.Minute() == 0 {
+			return t.Format("3 PM")
+		}
+		return t.Format("3:04 PM")
+	}
+	return fmt.Sprintf("%s - %s", format(slot.Start), format(slot.End))
+}
+
+// formatSlotLength describes a slot length for the legend, such as
+// "15-minute" or "1-hour"
+func formatSlotLength(length time.Duration) string {
+	switch {
+	case length <= 0:
+		return ""
+	case length%time.Hour == 0:
+		return fmt.Sprintf("%d-hour", length/time.Hour)
+	default:
+		return fmt.Sprintf("%d-minute", length/time.Minute)
+	}
+}
+
+// staleSince returns when the oldest out-of-date feed in the schedule was
+// last fetched, or "" when every feed is current
+func (g *Generator) staleSince(schedule *calendar.WeekSchedule) string {
+	var oldest time.Time
+	for _, feed := range schedule.StaleFeeds {
+		if oldest.IsZero() || feed.FetchedAt.Before(oldest) {
+			oldest = feed.FetchedAt
+		}
+	}
+	if oldest.IsZero() {
+		return ""
+	}
+	return oldest.In(schedule.TimeZone).Format("2006-01-02 15:04 MST")
+}
+
+// formatMissing describes how many calendars are missing from the schedule.
+// Feed IDs are left out as the schedule is published.
+func formatMissing(count int) string {
+	switch count {
+	case 0:
+		return ""
+	case 1:
+		return "1 calendar"
+	default:
+		return fmt.Sprintf("%d calendars", count)
+	}
+}
+
+// buildAllDay builds the all-day annotation row, or nil when no day has one
+func (g *Generator) buildAllDay(schedule *calendar.WeekSchedule, days []DayColumnData) []DaySlotData {
+	var row []DaySlotData
+	found := false
+
+	for _, day := range days {
+		var cell DaySlotData
+		if len(schedule.AllDay[day.Weekday]) > 0 {
+			cell = DaySlotData{Status: "📌", Title: "All-day event"}
+			found = true
+		}
+		row = append(row, cell)
+	}
+
+	if !found {
+		return nil
+	}
+	return row
+}
+
 // buildDaySlot converts a calendar time slot into template data
 func (g *Generator) buildDaySlot(slot calendar.TimeSlot) DaySlotData {
 	var status, title, link string
@@ -163,6 +296,9 @@ func (g *Generator) buildDaySlot(slot calendar.TimeSlot) DaySlotData {
 	case calendar.StatusTentative:
 		status = "🟡"
 		title = "Tentative"
+	case calendar.StatusOutOfOffice:
+		status = "🌴"
+		title = "Out of Office"
 	}
 
 	return DaySlotData{
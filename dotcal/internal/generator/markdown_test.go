@@ -121,6 +121,161 @@ func TestGenerateWeekSchedule(t *testing.T) {
 			t.Errorf("expected output to contain %q", expected)
 		}
 	}
+
+	if strings.Contains(output, "Data may be out of date") {
+		t.Error("expected no staleness notice when all feeds are current")
+	}
+
+	t.Run("stale feeds", func(t *testing.T) {
+		stale := *schedule
+		stale.StaleFeeds = []calendar.StaleFeed{
+			{ID: "feed-2", FetchedAt: time.Date(2025, 2, 9, 18, 0, 0, 0, time.UTC)},
+			{ID: "feed-1", FetchedAt: time.Date(2025, 2, 8, 7, 30, 0, 0, time.UTC)},
+		}
+
+		output, err := g.GenerateWeekSchedule(&stale)
+		if err != nil {
+			t.Fatalf("failed to generate schedule: %v", err)
+		}
+		expected := "Data may be out of date:** some calendars could not be refreshed and are shown as of 2025-02-08 07:30 UTC"
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, output)
+		}
+		if strings.Contains(output, "Data may be incomplete") {
+			t.Error("expected no missing calendars notice when every feed has a copy")
+		}
+	})
+
+	t.Run("missing feeds", func(t *testing.T) {
+		missing := *schedule
+		missing.MissingFeeds = []string{"work-calendar", "team-calendar"}
+
+		output, err := g.GenerateWeekSchedule(&missing)
+		if err != nil {
+			t.Fatalf("failed to generate schedule: %v", err)
+		}
+		expected := "Data may be incomplete:** 2 calendars could not be fetched"
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, output)
+		}
+		if strings.Contains(output, "work-calendar") {
+			t.Error("expected feed IDs to be left out of the published schedule")
+		}
+	})
+}
+
+func TestGenerateWorkingHours(t *testing.T) {
+	g, err := NewGenerator("../templates")
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	hours, err := calendar.ParseWorkingHours("mon-wed 07:00-08:00; thu 08:00-09:00")
+	if err != nil {
+		t.Fatalf("failed to parse working hours: %v", err)
+	}
+	merger := calendar.NewMerger(time.UTC)
+	merger.SetWorkingHours(hours)
+	schedule := merger.MergeEvents([]calendar.Event{{
+		Start:  time.Date(2025, 2, 13, 8, 0, 0, 0, time.UTC),
+		End:    time.Date(2025, 2, 13, 8, 30, 0, 0, time.UTC),
+		Status: calendar.StatusBusy,
+	}}, 2025, 7)
+
+	output, err := g.GenerateWeekSchedule(schedule)
+	if err != nil {
+		t.Fatalf("failed to generate schedule: %v", err)
+	}
+
+	expectedElements := []string{
+		"Week of February 10 - February 13",
+		"| Time | Monday | Tuesday | Wednesday | Thursday |\n|:----:|:---:|:---:|:---:|:---:|\n",
+		"| 7:00 AM - 7:30 AM | 🟢 [Available](https://cal.com) | 🟢 [Available](https://cal.com) | 🟢 [Available](https://cal.com) |  |",
+		"| 8:00 AM - 8:30 AM |  |  |  | 🔴 Busy |",
+	}
+	for _, expected := range expectedElements {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, output)
+		}
+	}
+	if strings.Contains(output, "Friday") {
+		t.Error("expected no column for a day off")
+	}
+}
+
+func TestGenerateSlotLength(t *testing.T) {
+	g, err := NewGenerator("../templates")
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	tests := []struct {
+		length   time.Duration
+		rows     int
+		expected []string
+	}{
+		{
+			length:   15 * time.Minute,
+			rows:     32,
+			expected: []string{"| 9:00 AM - 9:15 AM |", "| 4:45 PM - 5:00 PM |", "Each row is a 15-minute slot"},
+		},
+		{
+			length:   time.Hour,
+			rows:     8,
+			expected: []string{"| 9 AM - 10 AM |", "| 4 PM - 5 PM |", "Each row is a 1-hour slot"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.length.String(), func(t *testing.T) {
+			merger := calendar.NewMerger(time.UTC)
+			merger.SetSlotLength(tt.length)
+			output, err := g.GenerateWeekSchedule(merger.MergeEvents(nil, 2025, 7))
+			if err != nil {
+				t.Fatalf("failed to generate schedule: %v", err)
+			}
+
+			for _, expected := range tt.expected {
+				if !strings.Contains(output, expected) {
+					t.Errorf("expected output to contain %q", expected)
+				}
+			}
+			if rows := strings.Count(output, "M |"); rows != tt.rows {
+				t.Errorf("expected %d rows, got %d", tt.rows, rows)
+			}
+		})
+	}
+}
+
+func TestBuildAllDay(t *testing.T) {
+	g := &Generator{}
+
+	schedule := &calendar.WeekSchedule{
+		Days:   make(map[time.Weekday][]calendar.TimeSlot),
+		AllDay: make(map[time.Weekday][]calendar.Event),
+	}
+	for day := time.Monday; day <= time.Friday; day++ {
+		schedule.Days[day] = nil
+	}
+	days := g.buildDays(schedule, time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC))
+	if row := g.buildAllDay(schedule, days); row != nil {
+		t.Errorf("expected no all-day row, got %v", row)
+	}
+
+	schedule.AllDay[time.Wednesday] = []calendar.Event{{Title: "Company holiday", AllDay: true}}
+	row := g.buildAllDay(schedule, days)
+	if len(row) != 5 {
+		t.Fatalf("expected 5 cells, got %d", len(row))
+	}
+	if row[2].Title != "All-day event" {
+		t.Errorf("expected Wednesday to be annotated, got %v", row[2])
+	}
+	if row[0].Title != "" {
+		t.Errorf("expected Monday to be empty, got %v", row[0])
+	}
+	if row[2].Title == "Company holiday" {
+		t.Error("expected event title to stay private")
+	}
 }
 
 func TestBuildDaySlot(t *testing.T) {
@@ -158,6 +313,15 @@ func TestBuildDaySlot(t *testing.T) {
 				Link:   "",
 			},
 		},
+		{
+			name: "out of office slot",
+			slot: calendar.TimeSlot{Status: calendar.StatusOutOfOffice},
+			expected: DaySlotData{
+				Status: "🌴",
+				Title:  "Out of Office",
+				Link:   "",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"io"
 	"os"
 
 	"go.uber.org/zap"
@@ -29,6 +30,19 @@ func init() {
 	sugar = logger.Sugar()
 }
 
+// Capture sends messages of every level to w, returning a function that
+// restores the previous logger. Used by tests that check what is logged.
+func Capture(w io.Writer) func() {
+	previous := sugar
+	core := zapcore.NewCore(
+		zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig()),
+		zapcore.AddSync(w),
+		zap.DebugLevel,
+	)
+	sugar = zap.New(core).Sugar()
+	return func() { sugar = previous }
+}
+
 // Debug logs a debug message
 func Debug(format string, v ...interface{}) {
 	sugar.Debugf(format, v...)
@@ -109,6 +109,22 @@ func TestLoggerInit(t *testing.T) {
 	})
 }
 
+func TestCapture(t *testing.T) {
+	logs := &zaptest.Buffer{}
+	restore := Capture(logs)
+	Debug("captured %d", 1)
+	restore()
+	Debug("not captured")
+
+	output := logs.String()
+	if !strings.Contains(output, "captured 1") {
+		t.Errorf("Expected debug message to be captured, got '%s'", output)
+	}
+	if strings.Contains(output, "not captured") {
+		t.Error("Expected messages after restoring to go to the previous logger")
+	}
+}
+
 func TestLoggerMethods(t *testing.T) {
 	// Create a buffer to capture log output
 	logs := &zaptest.Buffer{}